@@ -18,6 +18,9 @@ WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
 package v1alpha1
 
 import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -25,6 +28,127 @@ import (
 type ProjectSpec struct {
 	// +optional
 	Access []SubjectRef `json:"access,omitempty"`
+
+	// Parent is the name of the parent project. The subjects in the access
+	// of every ancestor are also given access to the project.
+	// +optional
+	Parent string `json:"parent,omitempty"`
+
+	// NamespaceMetadata is kept in sync with the labels and annotations of
+	// the project namespace, subject to the operator's key policy
+	// +optional
+	NamespaceMetadata *NamespaceMetadata `json:"namespaceMetadata,omitempty"`
+
+	// DeletionPolicy decides what happens to the project namespace when the
+	// project is deleted
+	// +optional
+	// +kubebuilder:default=Delete
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// AdoptExistingNamespace allows the project to take ownership of an
+	// existing namespace of the same name. Only members of the webhook's
+	// admin groups may create such a project.
+	// +optional
+	AdoptExistingNamespace bool `json:"adoptExistingNamespace,omitempty"`
+
+	// Quota is applied to the project namespace as a ResourceQuota, in place
+	// of the quota of the project class
+	// +optional
+	Quota *corev1.ResourceQuotaSpec `json:"quota,omitempty"`
+
+	// Limits are applied to the project namespace as a LimitRange, in place
+	// of the limits of the project class
+	// +optional
+	Limits []corev1.LimitRangeItem `json:"limits,omitempty"`
+
+	// Network controls which other projects may connect to the project
+	// +optional
+	Network *ProjectNetwork `json:"network,omitempty"`
+
+	// PodSecurity sets the Pod Security Admission levels of the project
+	// namespace, subject to the operator's maximum
+	// +optional
+	PodSecurity *PodSecurity `json:"podSecurity,omitempty"`
+
+	// ProjectClassName is the name of the ProjectClass of the project. The
+	// default ProjectClass is used when it is empty.
+	// +optional
+	ProjectClassName string `json:"projectClassName,omitempty"`
+}
+
+// AdoptExistingNamespaceAnnotation may be set to "true" on a Project as an
+// alternative to spec.adoptExistingNamespace
+const AdoptExistingNamespaceAnnotation = "projects.vmware.com/adopt-existing-namespace"
+
+// ProjectLabel is kept on every project namespace with the name of its project
+// so that network policies can select project namespaces
+const ProjectLabel = "projects.vmware.com/project"
+
+// +kubebuilder:validation:Enum=privileged;baseline;restricted
+type PodSecurityLevel string
+
+const (
+	PodSecurityPrivileged PodSecurityLevel = "privileged"
+	PodSecurityBaseline   PodSecurityLevel = "baseline"
+	PodSecurityRestricted PodSecurityLevel = "restricted"
+)
+
+// PodSecurity holds the Pod Security Admission level of each mode. The
+// operator's default level is enforced when Enforce is empty, and Audit and
+// Warn default to the enforced level.
+type PodSecurity struct {
+	// +optional
+	Enforce PodSecurityLevel `json:"enforce,omitempty"`
+	// +optional
+	Audit PodSecurityLevel `json:"audit,omitempty"`
+	// +optional
+	Warn PodSecurityLevel `json:"warn,omitempty"`
+}
+
+// ProjectNetwork describes the network isolation of a project
+type ProjectNetwork struct {
+	// Isolated enables a default-deny ingress NetworkPolicy in the project
+	// namespace. Traffic from within the namespace and from the AllowFrom
+	// projects is still allowed. The operator's default is used when it is
+	// not set.
+	// +optional
+	Isolated *bool `json:"isolated,omitempty"`
+
+	// AllowFrom lists the projects whose namespaces may connect to an
+	// isolated project
+	// +optional
+	AllowFrom []ProjectPeer `json:"allowFrom,omitempty"`
+}
+
+// ProjectPeer selects other projects by name or by label. Exactly one of the
+// fields should be set.
+type ProjectPeer struct {
+	// +optional
+	ProjectName string `json:"projectName,omitempty"`
+
+	// ProjectSelector selects projects by their labels
+	// +optional
+	ProjectSelector *metav1.LabelSelector `json:"projectSelector,omitempty"`
+}
+
+// DeletionPolicy is one of:
+//   - Delete: the namespace and the project RBAC are deleted
+//   - Retain: the namespace is kept and the project RBAC is deleted
+//   - Orphan: the namespace and the project RBAC are both kept
+// +kubebuilder:validation:Enum=Delete;Retain;Orphan
+type DeletionPolicy string
+
+const (
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+)
+
+type NamespaceMetadata struct {
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
 // +kubebuilder:validation:Enum=ServiceAccount;User;Group
@@ -36,14 +160,102 @@ type SubjectRef struct {
 
 	// +optional
 	Namespace string `json:"namespace,omitempty"`
+
+	// Role is the name of one of the role profiles configured for the
+	// operator. The operator's default ClusterRole is used when it is empty.
+	// +optional
+	Role string `json:"role,omitempty"`
+
+	// NotBefore is the time from which the subject is granted access
+	// +optional
+	NotBefore *metav1.Time `json:"notBefore,omitempty"`
+
+	// ExpiresAt is the time at which the subject's access is revoked
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+}
+
+// ActiveAt reports whether the subject is granted access at the given time
+func (s SubjectRef) ActiveAt(now time.Time) bool {
+	if s.NotBefore != nil && now.Before(s.NotBefore.Time) {
+		return false
+	}
+	return s.ExpiresAt == nil || now.Before(s.ExpiresAt.Time)
+}
+
+// ExpiredAt reports whether the subject's access has expired at the given time
+func (s SubjectRef) ExpiredAt(now time.Time) bool {
+	return s.ExpiresAt != nil && !now.Before(s.ExpiresAt.Time)
 }
 
+// +kubebuilder:validation:Enum=Pending;Active;Terminating;Failed
+type ProjectPhase string
+
+const (
+	ProjectPending     ProjectPhase = "Pending"
+	ProjectActive      ProjectPhase = "Active"
+	ProjectTerminating ProjectPhase = "Terminating"
+	ProjectFailed      ProjectPhase = "Failed"
+)
+
+// Condition types reported in ProjectStatus.Conditions
+const (
+	// NamespaceReady indicates whether the project namespace has been provisioned
+	NamespaceReady = "NamespaceReady"
+	// RBACReady indicates whether the project ClusterRole, ClusterRoleBinding and RoleBinding have been provisioned
+	RBACReady = "RBACReady"
+	// PoliciesReady indicates whether the ResourceQuota, LimitRange and NetworkPolicy of the project class have been applied
+	PoliciesReady = "PoliciesReady"
+	// Ready indicates whether all of the project resources have been provisioned
+	Ready = "Ready"
+)
+
 // ProjectStatus defines the observed state of Project
 type ProjectStatus struct {
+	// +optional
+	Phase ProjectPhase `json:"phase,omitempty"`
+
+	// Namespace is the name of the namespace backing the project
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// ObservedGeneration is the most recent generation reconciled by the operator
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ProjectClassName is the name of the ProjectClass applied to the project
+	// +optional
+	ProjectClassName string `json:"projectClassName,omitempty"`
+
+	// Quota mirrors the hard limits and current usage of the project
+	// ResourceQuota
+	// +optional
+	Quota *corev1.ResourceQuotaStatus `json:"quota,omitempty"`
+
+	// UnmanagedRoleBindings lists the RoleBindings that were already present
+	// in an adopted namespace. They are left in place by the operator.
+	// +optional
+	UnmanagedRoleBindings []string `json:"unmanagedRoleBindings,omitempty"`
+
+	// ExpiredAccess lists the subjects in spec.access whose access has
+	// expired
+	// +optional
+	ExpiredAccess []SubjectRef `json:"expiredAccess,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Namespace",type=string,JSONPath=`.status.namespace`
+// +kubebuilder:printcolumn:name="Parent",type=string,JSONPath=`.spec.parent`,priority=1
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // Project is the Schema for the projects API
 type Project struct {
@@ -54,6 +266,12 @@ type Project struct {
 	Status ProjectStatus `json:"status,omitempty"`
 }
 
+// AdoptsExistingNamespace reports whether the project asks to take ownership
+// of an existing namespace
+func (p *Project) AdoptsExistingNamespace() bool {
+	return p.Spec.AdoptExistingNamespace || p.Annotations[AdoptExistingNamespaceAnnotation] == "true"
+}
+
 // +kubebuilder:object:root=true
 
 // ProjectList contains a list of Project
@@ -23,12 +23,83 @@ import (
 
 // ProjectAccessSpec defines the desired state of ProjectAccess
 type ProjectAccessSpec struct {
+	// User and Groups ask for the projects of another user instead of the
+	// requesting user. They are only honoured for requesters that may
+	// impersonate on projectaccesses.
+	// +optional
+	User string `json:"user,omitempty"`
+	// +optional
+	Groups []string `json:"groups,omitempty"`
+
+	// LabelSelector only lists projects whose labels match
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+
+	// NamePrefix only lists projects whose name starts with the prefix
+	// +optional
+	NamePrefix string `json:"namePrefix,omitempty"`
+
+	// Limit is the maximum number of projects to list. All projects are
+	// listed when it is zero.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Limit int64 `json:"limit,omitempty"`
+
+	// Continue is the token from the status of a previous ProjectAccess
+	// with the same query, to list the projects that follow it
+	// +optional
+	Continue string `json:"continue,omitempty"`
+
+	// Refresh keeps status.projects up to date as Projects change. A
+	// ProjectAccess that is refreshed is not garbage collected.
+	// +optional
+	Refresh bool `json:"refresh,omitempty"`
 }
 
+// ProjectAccessRefreshAnnotation records the state of the Projects that the
+// status of a refreshed ProjectAccess was last listed from
+const ProjectAccessRefreshAnnotation = "projects.vmware.com/refreshed-for"
+
+// ImpersonateVerb is the verb on projectaccesses that allows a requester to
+// ask for the projects of another user
+const ImpersonateVerb = "impersonate"
+
 // ProjectAccessStatus defines the observed state of ProjectAccess
 type ProjectAccessStatus struct {
+	// Projects are sorted by name
 	// +optional
-	Projects []string `json:"projects,omitempty"`
+	Projects []AccessibleProject `json:"projects,omitempty"`
+
+	// Continue is set when there are more projects to list. It is passed as
+	// spec.continue to list the next page.
+	// +optional
+	Continue string `json:"continue,omitempty"`
+
+	// User and Groups are the user the projects were listed for
+	// +optional
+	User string `json:"user,omitempty"`
+	// +optional
+	Groups []string `json:"groups,omitempty"`
+}
+
+// AccessibleProject is a project the user has access to and why
+type AccessibleProject struct {
+	Name string `json:"name"`
+
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Role is the most privileged role the user has in the project. It is
+	// empty for the operator's default role.
+	// +optional
+	Role string `json:"role,omitempty"`
+
+	// Admin is true when the user may update and delete the project
+	Admin bool `json:"admin"`
+
+	// Subjects are the entries in the project's access, including those it
+	// inherits, that match the user
+	Subjects []SubjectRef `json:"subjects"`
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,80 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+/*
+Unauthorized use, copying or distribution of any source code in this
+repository via any medium is strictly prohibited without the author's
+express written consent.
+
+ANY AUTHORIZED USE OF OR ACCESS TO THE SOFTWARE IS "AS IS", WITHOUT
+WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO
+THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+OF CONTRACT,TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultProjectClassAnnotation marks the ProjectClass used by projects that
+// do not set spec.projectClassName
+const DefaultProjectClassAnnotation = "projects.vmware.com/is-default-class"
+
+// ProjectClassSpec defines the resources given to every project of the class
+type ProjectClassSpec struct {
+	// ClusterRoles are bound in the project namespace to the subjects that
+	// do not request a role, instead of the operator's default ClusterRole
+	// +optional
+	ClusterRoles []string `json:"clusterRoles,omitempty"`
+
+	// ResourceQuota is created in the project namespace
+	// +optional
+	ResourceQuota *corev1.ResourceQuotaSpec `json:"resourceQuota,omitempty"`
+
+	// LimitRange is created in the project namespace
+	// +optional
+	LimitRange *corev1.LimitRangeSpec `json:"limitRange,omitempty"`
+
+	// NetworkPolicy is created in the project namespace as a baseline
+	// +optional
+	NetworkPolicy *networkingv1.NetworkPolicySpec `json:"networkPolicy,omitempty"`
+
+	// NamespaceLabels are set on the project namespace
+	// +optional
+	NamespaceLabels map[string]string `json:"namespaceLabels,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+
+// ProjectClass is the Schema for the projectclasses API
+type ProjectClass struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ProjectClassSpec `json:"spec,omitempty"`
+}
+
+// IsDefault reports whether the class applies to projects that do not name one
+func (c *ProjectClass) IsDefault() bool {
+	return c.Annotations[DefaultProjectClassAnnotation] == "true"
+}
+
+// +kubebuilder:object:root=true
+
+// ProjectClassList contains a list of ProjectClass
+type ProjectClassList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProjectClass `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ProjectClass{}, &ProjectClassList{})
+}
@@ -20,11 +20,16 @@ package main
 import (
 	"errors"
 	"flag"
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	projects "github.com/pivotal/projects-operator/api/v1alpha1"
 	"github.com/pivotal/projects-operator/controllers"
+	"github.com/pivotal/projects-operator/pkg/keypolicy"
+	"github.com/pivotal/projects-operator/pkg/podsecurity"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -93,6 +98,52 @@ func main() {
 		}
 	}
 
+	var namespaceDeletionTimeout time.Duration
+	if namespaceDeletionTimeoutString, ok := os.LookupEnv("NAMESPACE_DELETION_TIMEOUT"); ok && namespaceDeletionTimeoutString != "" {
+		namespaceDeletionTimeout, err = time.ParseDuration(namespaceDeletionTimeoutString)
+		if err != nil {
+			err = errors.New("NAMESPACE_DELETION_TIMEOUT env must be set to a duration")
+			setupLog.Error(err, "unable to create controller", "controller", "Project")
+			os.Exit(1)
+		}
+	}
+
+	namespaceMetadataPolicy := keypolicy.DefaultPolicy()
+	if allowedKeys, ok := os.LookupEnv("NAMESPACE_METADATA_ALLOWED_KEYS"); ok {
+		namespaceMetadataPolicy.Allowed = splitList(allowedKeys)
+	}
+	if deniedKeys, ok := os.LookupEnv("NAMESPACE_METADATA_DENIED_KEYS"); ok {
+		namespaceMetadataPolicy.Denied = splitList(deniedKeys)
+	}
+
+	var networkIsolation bool
+	if networkIsolationString, ok := os.LookupEnv("NETWORK_ISOLATION"); ok && networkIsolationString != "" {
+		networkIsolation, err = strconv.ParseBool(networkIsolationString)
+		if err != nil {
+			err = errors.New("NETWORK_ISOLATION env must be set to a boolean")
+			setupLog.Error(err, "unable to create controller", "controller", "Project")
+			os.Exit(1)
+		}
+	}
+
+	var podSecurity podsecurity.Config
+	if podSecurity.Default, err = podsecurity.ParseLevel(os.Getenv("POD_SECURITY_DEFAULT_LEVEL")); err != nil {
+		err = errors.New("POD_SECURITY_DEFAULT_LEVEL env must be one of privileged, baseline or restricted")
+		setupLog.Error(err, "unable to create controller", "controller", "Project")
+		os.Exit(1)
+	}
+	if podSecurity.Maximum, err = podsecurity.ParseLevel(os.Getenv("POD_SECURITY_MAXIMUM_LEVEL")); err != nil {
+		err = errors.New("POD_SECURITY_MAXIMUM_LEVEL env must be one of privileged, baseline or restricted")
+		setupLog.Error(err, "unable to create controller", "controller", "Project")
+		os.Exit(1)
+	}
+
+	roleProfiles, err := parseRoleProfiles(os.Getenv("ROLE_PROFILES"), splitList(os.Getenv("ADMIN_ROLE_PROFILES")))
+	if err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Project")
+		os.Exit(1)
+	}
+
 	if err = (&controllers.ProjectReconciler{
 		Client: mgr.GetClient(),
 		Log:    ctrl.Log.WithName("controllers").WithName("Project"),
@@ -102,11 +153,46 @@ func main() {
 			Kind:     "ClusterRole",
 			Name:     clusterRole,
 		},
+		RoleProfiles:             roleProfiles,
+		NetworkIsolation:         networkIsolation,
+		NamespaceDeletionTimeout: namespaceDeletionTimeout,
+		NamespaceMetadataPolicy:  &namespaceMetadataPolicy,
+		PodSecurity:              podSecurity,
 	}).SetupWithManager(mgr, maxConcurrentReconciles); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Project")
 		os.Exit(1)
 	}
 
+	var projectAccessTTL time.Duration
+	if projectAccessTTLString, ok := os.LookupEnv("PROJECT_ACCESS_TTL"); ok && projectAccessTTLString != "" {
+		projectAccessTTL, err = time.ParseDuration(projectAccessTTLString)
+		if err != nil {
+			err = errors.New("PROJECT_ACCESS_TTL env must be set to a duration")
+			setupLog.Error(err, "unable to create controller", "controller", "ProjectAccess")
+			os.Exit(1)
+		}
+	}
+
+	var projectAccessRefreshInterval time.Duration
+	if refreshIntervalString, ok := os.LookupEnv("PROJECT_ACCESS_REFRESH_INTERVAL"); ok && refreshIntervalString != "" {
+		projectAccessRefreshInterval, err = time.ParseDuration(refreshIntervalString)
+		if err != nil {
+			err = errors.New("PROJECT_ACCESS_REFRESH_INTERVAL env must be set to a duration")
+			setupLog.Error(err, "unable to create controller", "controller", "ProjectAccess")
+			os.Exit(1)
+		}
+	}
+
+	if err = (&controllers.ProjectAccessReconciler{
+		Client:          mgr.GetClient(),
+		Log:             ctrl.Log.WithName("controllers").WithName("ProjectAccess"),
+		TTL:             projectAccessTTL,
+		RefreshInterval: projectAccessRefreshInterval,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ProjectAccess")
+		os.Exit(1)
+	}
+
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("starting manager")
@@ -115,3 +201,42 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseRoleProfiles parses a comma-separated list of name=clusterrole pairs
+func parseRoleProfiles(list string, adminProfiles []string) ([]controllers.RoleProfile, error) {
+	var profiles []controllers.RoleProfile
+	for _, item := range splitList(list) {
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("ROLE_PROFILES env entry '%s' must be of the form name=clusterrole", item)
+		}
+
+		admin := false
+		for _, adminProfile := range adminProfiles {
+			if adminProfile == parts[0] {
+				admin = true
+			}
+		}
+
+		profiles = append(profiles, controllers.RoleProfile{
+			Name: parts[0],
+			ClusterRoleRef: rbacv1.RoleRef{
+				APIGroup: "rbac.authorization.k8s.io",
+				Kind:     "ClusterRole",
+				Name:     parts[1],
+			},
+			Admin: admin,
+		})
+	}
+	return profiles, nil
+}
@@ -4,22 +4,61 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	"github.com/pivotal/projects-operator/pkg/certs"
+	"github.com/pivotal/projects-operator/pkg/podsecurity"
+	"github.com/pivotal/projects-operator/pkg/server"
+	"github.com/pivotal/projects-operator/pkg/userprojects"
 	"github.com/pivotal/projects-operator/pkg/webhook"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2/klogr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-const port = 8080
+const (
+	defaultPort     = 8080
+	healthProbePort = 8081
+	apiPort         = 8443
+
+	// accessReviewCacheTTL is how long the RBAC access of a user to a
+	// project is cached for
+	accessReviewCacheTTL = 30 * time.Second
+
+	// caValidity and certValidity are the lifetimes of the self-managed CA
+	// and serving certificate, certCheckInterval how often they are checked
+	caValidity        = 365 * 24 * time.Hour
+	certValidity      = 90 * 24 * time.Hour
+	certCheckInterval = 10 * time.Minute
+
+	// the defaults of the server timeouts. The API server waits at most 30s
+	// for a webhook, and the shutdown delay and timeout together must be
+	// shorter than the terminationGracePeriodSeconds of the pod.
+	defaultReadTimeout     = 10 * time.Second
+	defaultWriteTimeout    = 30 * time.Second
+	defaultShutdownDelay   = 15 * time.Second
+	defaultShutdownTimeout = 25 * time.Second
+)
 
 var (
 	scheme        = runtime.NewScheme()
@@ -34,34 +73,334 @@ func init() {
 func main() {
 	ctrl.SetLogger(klogr.New())
 
-	kubeClient, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+	port, serverOptions, err := loadServerOptions()
+	if err != nil {
+		webhookLogger.Error(err, "Failed to load server options")
+		os.Exit(1)
+	}
+
+	// Projects and namespaces are read from informer caches rather than
+	// listed from the API server on every admission request. The manager
+	// also runs the servers, and on SIGTERM keeps serving the health probes
+	// while it stops them and waits for their in-flight requests.
+	gracefulShutdownTimeout := serverOptions.ShutdownDelay + serverOptions.ShutdownTimeout + 5*time.Second
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+		Scheme:                  scheme,
+		MetricsBindAddress:      "0",
+		HealthProbeBindAddress:  fmt.Sprintf(":%d", healthProbePort),
+		GracefulShutdownTimeout: &gracefulShutdownTimeout,
+	})
+	if err != nil {
+		webhookLogger.Error(err, "Failed to build a manager")
+		os.Exit(1)
+	}
+
+	ctx := ctrl.SetupSignalHandler()
+
+	if err := webhook.IndexProjects(ctx, mgr.GetFieldIndexer()); err != nil {
+		webhookLogger.Error(err, "Failed to index projects")
+		os.Exit(1)
+	}
+
+	for _, obj := range []client.Object{&projects.Project{}, &corev1.Namespace{}} {
+		if _, err := mgr.GetCache().GetInformer(ctx, obj); err != nil {
+			webhookLogger.Error(err, "Failed to create informer")
+			os.Exit(1)
+		}
+	}
+
+	// watches of the userprojects API are told about every change to a project
+	notifier := userprojects.NewNotifier()
+	projectInformer, _ := mgr.GetCache().GetInformer(ctx, &projects.Project{})
+	if _, err := projectInformer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { notifier.Notify() },
+		UpdateFunc: func(interface{}, interface{}) { notifier.Notify() },
+		DeleteFunc: func(interface{}) { notifier.Notify() },
+	}); err != nil {
+		webhookLogger.Error(err, "Failed to watch projects")
+		os.Exit(1)
+	}
+
+	var cachesSynced int32
+	if err := mgr.AddReadyzCheck("caches", func(_ *http.Request) error {
+		if atomic.LoadInt32(&cachesSynced) == 0 {
+			return errors.New("caches have not synced")
+		}
+		return nil
+	}); err != nil {
+		webhookLogger.Error(err, "Failed to add readiness check")
+		os.Exit(1)
+	}
+
+	getCertificate, err := loadCertificate(ctx, mgr)
 	if err != nil {
-		webhookLogger.Error(err, "Failed to build a Kubernetes client")
+		webhookLogger.Error(err, "Failed to load serving certificate")
 		os.Exit(1)
 	}
 
+	go func() {
+		if mgr.GetCache().WaitForCacheSync(ctx) {
+			webhookLogger.Info("caches synced")
+			atomic.StoreInt32(&cachesSynced, 1)
+		}
+	}()
+
+	kubeClient := mgr.GetClient()
+
 	projectFetcher := webhook.NewProjectFetcher(kubeClient)
 	namespaceFetcher := webhook.NewNamespaceFetcher(kubeClient)
-	projectFilterer := webhook.NewProjectFilterer()
+	accessReviewer := webhook.NewAccessReviewer(kubeClient)
 
-	handler := webhook.NewHandler(webhookLogger.WithName("handler"), namespaceFetcher, projectFetcher, projectFilterer)
+	config := webhook.Config{
+		AdminGroups:       splitList(os.Getenv("ADMIN_GROUPS")),
+		OperatorNamespace: os.Getenv("OPERATOR_NAMESPACE"),
+		IdentityPrefixes:  splitList(os.Getenv("IDENTITY_PREFIXES")),
+	}
 
-	keyPath := os.Getenv("TLS_KEY_FILEPATH")
-	crtPath := os.Getenv("TLS_CERT_FILEPATH")
+	if serviceAccount := os.Getenv("OPERATOR_SERVICE_ACCOUNT"); serviceAccount != "" {
+		config.OperatorUsername = fmt.Sprintf("system:serviceaccount:%s:%s", config.OperatorNamespace, serviceAccount)
+	}
 
-	_, err = tls.LoadX509KeyPair(crtPath, keyPath)
-	if err != nil {
-		webhookLogger.Error(err, "Failed to load key pair")
+	if maxMembers := os.Getenv("MAX_PROJECT_MEMBERS"); maxMembers != "" {
+		if config.MaxMembers, err = strconv.Atoi(maxMembers); err != nil {
+			webhookLogger.Error(err, "Failed to parse MAX_PROJECT_MEMBERS")
+			os.Exit(1)
+		}
+	}
+
+	for env, resources := range map[string]*corev1.ResourceList{
+		"DEFAULT_QUOTA":            &config.DefaultQuota,
+		"MAX_QUOTA":                &config.MaxQuota,
+		"DEFAULT_CONTAINER_LIMITS": &config.DefaultContainerLimits,
+		"MAX_CONTAINER_LIMITS":     &config.MaxContainerLimits,
+	} {
+		if *resources, err = parseResourceList(os.Getenv(env)); err != nil {
+			webhookLogger.Error(err, "Failed to parse resources", "env", env)
+			os.Exit(1)
+		}
+	}
+
+	for env, level := range map[string]*projects.PodSecurityLevel{
+		"POD_SECURITY_DEFAULT_LEVEL": &config.PodSecurity.Default,
+		"POD_SECURITY_MAXIMUM_LEVEL": &config.PodSecurity.Maximum,
+	} {
+		if *level, err = podsecurity.ParseLevel(os.Getenv(env)); err != nil {
+			webhookLogger.Error(err, "Failed to parse pod security level", "env", env)
+			os.Exit(1)
+		}
+	}
+
+	if config.ProjectAccessMode, err = webhook.ParseAccessMode(os.Getenv("PROJECT_ACCESS_MODE")); err != nil {
+		webhookLogger.Error(err, "Failed to parse project access mode")
 		os.Exit(1)
 	}
 
-	webhookLogger.Info("starting webhook server")
-	if err = http.ListenAndServeTLS(
+	var projectFilterer webhook.ProjectFilterer = webhook.NewProjectFilterer(splitList(os.Getenv("ADMIN_ROLE_PROFILES"))...)
+	if config.ProjectAccessMode == webhook.AccessModeRBAC || config.ProjectAccessMode == webhook.AccessModeUnion {
+		// only the projects named by the ClusterRoles bound to a user are
+		// reviewed, which are found from cached and indexed bindings
+		if err := webhook.IndexClusterRoleBindings(ctx, mgr.GetFieldIndexer()); err != nil {
+			webhookLogger.Error(err, "Failed to index cluster role bindings")
+			os.Exit(1)
+		}
+		if _, err := mgr.GetCache().GetInformer(ctx, &rbacv1.ClusterRole{}); err != nil {
+			webhookLogger.Error(err, "Failed to create informer")
+			os.Exit(1)
+		}
+
+		rbacFilterer := webhook.NewRBACProjectFilterer(webhookLogger.WithName("rbac"), kubeClient, accessReviewer, accessReviewCacheTTL)
+		if config.ProjectAccessMode == webhook.AccessModeRBAC {
+			projectFilterer = rbacFilterer
+		} else {
+			projectFilterer = webhook.NewUnionProjectFilterer(projectFilterer, rbacFilterer)
+		}
+	}
+
+	handler := webhook.NewHandler(webhookLogger.WithName("handler"), config, namespaceFetcher, projectFetcher, projectFilterer, accessReviewer)
+
+	webhookServer := server.New(
+		webhookLogger.WithName("server"),
 		fmt.Sprintf(":%d", port),
-		crtPath,
-		keyPath,
 		handler,
-	); err != nil {
-		webhookLogger.Error(err, "ListenAndServe terminated")
+		nil,
+		getCertificate,
+		serverOptions,
+	)
+	if err := mgr.Add(webhookServer); err != nil {
+		webhookLogger.Error(err, "Failed to add webhook server")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("server", webhookServer.ReadyCheck); err != nil {
+		webhookLogger.Error(err, "Failed to add readiness check")
+		os.Exit(1)
+	}
+
+	requestHeaderConfig, err := userprojects.LoadRequestHeaderConfig(ctx, mgr.GetAPIReader())
+	if err != nil {
+		webhookLogger.Error(err, "Failed to load request header config, not serving the userprojects API")
+	} else {
+		// watches of the API stream for longer than any write timeout
+		apiOptions := serverOptions
+		apiOptions.WriteTimeout = 0
+
+		apiServer := server.New(
+			webhookLogger.WithName("userprojects-server"),
+			fmt.Sprintf(":%d", apiPort),
+			userprojects.NewServer(webhookLogger.WithName("userprojects"), requestHeaderConfig, config.ProjectAccessMode, projectFetcher, projectFilterer, notifier),
+			&tls.Config{
+				ClientAuth: tls.VerifyClientCertIfGiven,
+				ClientCAs:  requestHeaderConfig.ClientCAs,
+			},
+			getCertificate,
+			apiOptions,
+		)
+		if err := mgr.Add(apiServer); err != nil {
+			webhookLogger.Error(err, "Failed to add userprojects API server")
+			os.Exit(1)
+		}
+		if err := mgr.AddReadyzCheck("userprojects-server", apiServer.ReadyCheck); err != nil {
+			webhookLogger.Error(err, "Failed to add readiness check")
+			os.Exit(1)
+		}
+	}
+
+	if err := mgr.Start(ctx); err != nil {
+		webhookLogger.Error(err, "Manager terminated")
+		os.Exit(1)
+	}
+}
+
+// loadServerOptions reads the port, TLS and timeouts of the webhook server
+// from WEBHOOK_PORT, TLS_MIN_VERSION, TLS_CIPHER_SUITES, READ_TIMEOUT,
+// WRITE_TIMEOUT, SHUTDOWN_DELAY and SHUTDOWN_TIMEOUT
+func loadServerOptions() (int, server.Options, error) {
+	options := server.Options{
+		ReadTimeout:     defaultReadTimeout,
+		WriteTimeout:    defaultWriteTimeout,
+		ShutdownDelay:   defaultShutdownDelay,
+		ShutdownTimeout: defaultShutdownTimeout,
+	}
+
+	port := defaultPort
+	if value := os.Getenv("WEBHOOK_PORT"); value != "" {
+		var err error
+		if port, err = strconv.Atoi(value); err != nil {
+			return 0, options, fmt.Errorf("parsing WEBHOOK_PORT: %w", err)
+		}
+	}
+
+	var err error
+	if options.MinVersion, err = server.ParseTLSVersion(os.Getenv("TLS_MIN_VERSION")); err != nil {
+		return 0, options, err
+	}
+
+	if options.CipherSuites, err = server.ParseCipherSuites(splitList(os.Getenv("TLS_CIPHER_SUITES"))); err != nil {
+		return 0, options, err
+	}
+
+	for env, timeout := range map[string]*time.Duration{
+		"READ_TIMEOUT":     &options.ReadTimeout,
+		"WRITE_TIMEOUT":    &options.WriteTimeout,
+		"SHUTDOWN_DELAY":   &options.ShutdownDelay,
+		"SHUTDOWN_TIMEOUT": &options.ShutdownTimeout,
+	} {
+		if value := os.Getenv(env); value != "" {
+			if *timeout, err = time.ParseDuration(value); err != nil {
+				return 0, options, fmt.Errorf("parsing %s: %w", env, err)
+			}
+		}
+	}
+
+	return port, options, nil
+}
+
+// loadCertificate returns the GetCertificate func of the servers. With
+// SELF_MANAGED_CERTS the certificates are created, stored and rotated by a
+// certs.Rotator run by the manager, otherwise they are read from
+// TLS_CERT_FILEPATH and TLS_KEY_FILEPATH and reloaded when the files change.
+func loadCertificate(ctx context.Context, mgr ctrl.Manager) (func(*tls.ClientHelloInfo) (*tls.Certificate, error), error) {
+	if os.Getenv("SELF_MANAGED_CERTS") != "true" {
+		watcher, err := certwatcher.New(os.Getenv("TLS_CERT_FILEPATH"), os.Getenv("TLS_KEY_FILEPATH"))
+		if err != nil {
+			return nil, err
+		}
+
+		if err := mgr.Add(watcher); err != nil {
+			return nil, err
+		}
+
+		return watcher.GetCertificate, nil
+	}
+
+	// the secret is read without a cache so that the webhook does not need
+	// to watch every secret
+	kubeClient, err := client.New(mgr.GetConfig(), client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := os.Getenv("OPERATOR_NAMESPACE")
+	service := os.Getenv("WEBHOOK_SERVICE_NAME")
+
+	rotator := certs.NewRotator(webhookLogger.WithName("certs"), kubeClient, certs.Options{
+		Namespace:  namespace,
+		SecretName: os.Getenv("CERT_SECRET_NAME"),
+		DNSNames: []string{
+			service,
+			fmt.Sprintf("%s.%s", service, namespace),
+			fmt.Sprintf("%s.%s.svc", service, namespace),
+			fmt.Sprintf("%s.%s.svc.cluster.local", service, namespace),
+		},
+		MutatingWebhookConfigurations:   splitList(os.Getenv("MUTATING_WEBHOOK_CONFIGURATIONS")),
+		ValidatingWebhookConfigurations: splitList(os.Getenv("VALIDATING_WEBHOOK_CONFIGURATIONS")),
+		APIServices:                     splitList(os.Getenv("API_SERVICES")),
+		CAValidity:                      caValidity,
+		CertValidity:                    certValidity,
+		Interval:                        certCheckInterval,
+	})
+
+	// the servers only start once there is a certificate to serve. Replicas
+	// starting together may race to create the secret, the losers retry
+	// with the certificates of the winner.
+	if err := retry.OnError(retry.DefaultBackoff, func(err error) bool {
+		return apierrors.IsAlreadyExists(err) || apierrors.IsConflict(err)
+	}, func() error {
+		return rotator.Sync(ctx)
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := mgr.Add(rotator); err != nil {
+		return nil, err
+	}
+
+	return rotator.GetCertificate, nil
+}
+
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseResourceList parses a comma-separated list of name=quantity pairs
+func parseResourceList(list string) (corev1.ResourceList, error) {
+	resources := corev1.ResourceList{}
+	for _, item := range splitList(list) {
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("'%s' must be of the form name=quantity", item)
+		}
+
+		quantity, err := resource.ParseQuantity(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("'%s' has an invalid quantity: %w", item, err)
+		}
+		resources[corev1.ResourceName(parts[0])] = quantity
 	}
+	return resources, nil
 }
@@ -0,0 +1,54 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+/*
+Unauthorized use, copying or distribution of any source code in this
+repository via any medium is strictly prohibited without the author's
+express written consent.
+
+ANY AUTHORIZED USE OF OR ACCESS TO THE SOFTWARE IS "AS IS", WITHOUT
+WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO
+THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+OF CONTRACT,TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/package controllers
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+)
+
+// expiredGrants returns the grants whose access has expired
+func expiredGrants(grants []projects.SubjectRef, now time.Time) []projects.SubjectRef {
+	var expired []projects.SubjectRef
+	for _, grant := range grants {
+		if grant.ExpiredAt(now) {
+			expired = append(expired, grant)
+		}
+	}
+
+	return expired
+}
+
+// nextGrantChange returns the time until the next grant starts or expires,
+// or zero if no grant is due to change
+func nextGrantChange(grants []projects.SubjectRef, now time.Time) time.Duration {
+	var next time.Duration
+	for _, grant := range grants {
+		for _, at := range []*metav1.Time{grant.NotBefore, grant.ExpiresAt} {
+			if at == nil || !at.After(now) {
+				continue
+			}
+			if until := at.Sub(now); next == 0 || until < next {
+				next = until
+			}
+		}
+	}
+
+	return next
+}
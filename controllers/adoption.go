@@ -0,0 +1,67 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+/*
+Unauthorized use, copying or distribution of any source code in this
+repository via any medium is strictly prohibited without the author's
+express written consent.
+
+ANY AUTHORIZED USE OF OR ACCESS TO THE SOFTWARE IS "AS IS", WITHOUT
+WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO
+THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+OF CONTRACT,TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+)
+
+// namespaceConflictError is returned when a namespace with the project name
+// exists that the project neither owns nor asked to adopt
+type namespaceConflictError struct {
+	name string
+}
+
+func (e *namespaceConflictError) Error() string {
+	return fmt.Sprintf("namespace '%s' already exists and is not owned by the project", e.name)
+}
+
+func isOwnedBy(obj client.Object, project *projects.Project) bool {
+	for _, ownerReference := range obj.GetOwnerReferences() {
+		if isProjectReference(ownerReference, project) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// unmanagedRoleBindings returns the names of the RoleBindings in the project
+// namespace that were not created by the operator
+func (r *ProjectReconciler) unmanagedRoleBindings(ctx context.Context, project *projects.Project) ([]string, error) {
+	roleBindings := &rbacv1.RoleBindingList{}
+	if err := r.Client.List(ctx, roleBindings, client.InNamespace(project.Name)); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, roleBinding := range roleBindings.Items {
+		if !isOwnedBy(&roleBinding, project) {
+			names = append(names, roleBinding.Name)
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
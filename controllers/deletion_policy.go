@@ -0,0 +1,184 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+/*
+Unauthorized use, copying or distribution of any source code in this
+repository via any medium is strictly prohibited without the author's
+express written consent.
+
+ANY AUTHORIZED USE OF OR ACCESS TO THE SOFTWARE IS "AS IS", WITHOUT
+WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO
+THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+OF CONTRACT,TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	"github.com/pivotal/projects-operator/pkg/finalizer"
+)
+
+// FormerProjectLabel is set on namespaces that are kept after their project
+// has been deleted
+const FormerProjectLabel = "projects.vmware.com/former-project"
+
+// retainNamespace releases the project namespace so that it outlives the
+// project, replacing the project label with FormerProjectLabel. With
+// the Retain policy the project RBAC is deleted, with Orphan it
+// is released as well.
+func (r *ProjectReconciler) retainNamespace(ctx context.Context, project *projects.Project) error {
+	if !finalizer.HasFinalizer(project, projectFinalizer) {
+		return nil
+	}
+
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: project.Name}}
+	if err := r.release(ctx, project, namespace, func() {
+		releaseNamespaceMetadata(namespace)
+		if namespace.Labels == nil {
+			namespace.Labels = map[string]string{}
+		}
+		namespace.Labels[FormerProjectLabel] = project.Name
+	}); err != nil {
+		return err
+	}
+
+	rbac, err := r.projectRBAC(ctx, project)
+	if err != nil {
+		return err
+	}
+
+	for _, obj := range rbac {
+		if project.Spec.DeletionPolicy == projects.DeletionPolicyOrphan {
+			if err := r.release(ctx, project, obj, func() {}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := r.Client.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		r.Log.Info("deleting resource", "type", r.kindOf(obj), "name", obj.GetName())
+	}
+
+	// the policies stay with the namespace under either policy
+	policies, err := r.ownedInNamespace(ctx, project, &corev1.ResourceQuotaList{}, &corev1.LimitRangeList{}, &networkingv1.NetworkPolicyList{})
+	if err != nil {
+		return err
+	}
+
+	for _, obj := range policies {
+		if err := r.release(ctx, project, obj, func() {}); err != nil {
+			return err
+		}
+	}
+
+	return r.removeFinalizer(ctx, project)
+}
+
+// release removes the project owner reference from obj so that it is not
+// garbage collected along with the project
+func (r *ProjectReconciler) release(ctx context.Context, project *projects.Project, obj client.Object, mutate func()) error {
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	var ownerReferences []metav1.OwnerReference
+	for _, ownerReference := range obj.GetOwnerReferences() {
+		if !isProjectReference(ownerReference, project) {
+			ownerReferences = append(ownerReferences, ownerReference)
+		}
+	}
+	obj.SetOwnerReferences(ownerReferences)
+	mutate()
+
+	if err := r.Client.Update(ctx, obj); err != nil {
+		return err
+	}
+	r.Log.Info("releasing resource", "type", r.kindOf(obj), "name", obj.GetName())
+
+	return nil
+}
+
+func (r *ProjectReconciler) kindOf(obj client.Object) string {
+	gvk, err := apiutil.GVKForObject(obj, r.Scheme)
+	if err != nil {
+		return ""
+	}
+	return gvk.Kind
+}
+
+func isProjectReference(ownerReference metav1.OwnerReference, project *projects.Project) bool {
+	gv, err := schema.ParseGroupVersion(ownerReference.APIVersion)
+	if err != nil {
+		return false
+	}
+
+	return gv.Group == projects.GroupVersion.Group &&
+		ownerReference.Kind == "Project" &&
+		ownerReference.Name == project.Name
+}
+
+func (r *ProjectReconciler) projectRBAC(ctx context.Context, project *projects.Project) ([]client.Object, error) {
+	objs := []client.Object{
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: clusterRoleName(project)},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: clusterRoleBindingName(project)},
+		},
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: viewerClusterRoleName(project)},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: viewerClusterRoleBindingName(project)},
+		},
+	}
+
+	roleBindings, err := r.ownedInNamespace(ctx, project, &rbacv1.RoleBindingList{})
+	if err != nil {
+		return nil, err
+	}
+
+	return append(objs, roleBindings...), nil
+}
+
+// ownedInNamespace returns the objects of the given list types in the project
+// namespace that are owned by the project
+func (r *ProjectReconciler) ownedInNamespace(ctx context.Context, project *projects.Project, lists ...client.ObjectList) ([]client.Object, error) {
+	var owned []client.Object
+
+	for _, list := range lists {
+		if err := r.Client.List(ctx, list, client.InNamespace(project.Name)); err != nil {
+			return nil, err
+		}
+
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			obj, ok := item.(client.Object)
+			if ok && isOwnedBy(obj, project) {
+				owned = append(owned, obj)
+			}
+		}
+	}
+
+	return owned, nil
+}
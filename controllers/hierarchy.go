@@ -0,0 +1,59 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+/*
+Unauthorized use, copying or distribution of any source code in this
+repository via any medium is strictly prohibited without the author's
+express written consent.
+
+ANY AUTHORIZED USE OF OR ACCESS TO THE SOFTWARE IS "AS IS", WITHOUT
+WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO
+THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+OF CONTRACT,TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/package controllers
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	"github.com/pivotal/projects-operator/pkg/hierarchy"
+)
+
+// accessGrants returns the access of the project together with the access
+// it inherits from its ancestors, whatever their validity
+func (r *ProjectReconciler) accessGrants(ctx context.Context, project *projects.Project) ([]projects.SubjectRef, error) {
+	if project.Spec.Parent == "" {
+		return project.Spec.Access, nil
+	}
+
+	projectList := &projects.ProjectList{}
+	if err := r.Client.List(ctx, projectList); err != nil {
+		return nil, err
+	}
+
+	return hierarchy.Grants(*project, hierarchy.ByName(projectList.Items))
+}
+
+// projectDescendants maps a project to all projects below it, as a change to
+// its access changes what they inherit
+func (r *ProjectReconciler) projectDescendants(obj client.Object) []reconcile.Request {
+	projectList := &projects.ProjectList{}
+	if err := r.Client.List(context.Background(), projectList); err != nil {
+		r.Log.Error(err, "unable to list Projects", "project", obj.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, name := range hierarchy.Descendants(obj.GetName(), projectList.Items) {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: name}})
+	}
+
+	return requests
+}
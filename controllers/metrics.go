@@ -0,0 +1,115 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+/*
+Unauthorized use, copying or distribution of any source code in this
+repository via any medium is strictly prohibited without the author's
+express written consent.
+
+ANY AUTHORIZED USE OF OR ACCESS TO THE SOFTWARE IS "AS IS", WITHOUT
+WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO
+THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+OF CONTRACT,TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+)
+
+var driftRepairsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "projects_operator_drift_repairs_total",
+		Help: "Number of project resources recreated or reverted after being changed outside of the operator",
+	},
+	[]string{"resource"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(driftRepairsTotal)
+}
+
+// recordResult logs the outcome of a CreateOrUpdate and remembers the state
+// it left obj in. A change that ends in the same state the operator applied
+// last time only undid a change made by someone else, so it is a repair of
+// drift. Changes to the desired state, such as a class rollout or a new
+// grant, end in a different state and are not counted. Nothing is counted
+// for objects the operator has not applied since it started.
+func (r *ProjectReconciler) recordResult(project *projects.Project, resource string, obj client.Object, result controllerutil.OperationResult) {
+	r.Log.Info("creating/updating resource", "type", resource, "status", result)
+
+	state, err := appliedState(obj)
+	if err != nil {
+		r.Log.Error(err, "failed to hash resource", "type", resource, "name", obj.GetName())
+		return
+	}
+
+	previous, applied := r.appliedStates.Swap(appliedStateKey(project, resource, obj), state)
+	if result == controllerutil.OperationResultNone || !applied || previous != state {
+		return
+	}
+
+	r.Log.Info("repaired drift", "project", project.Name, "type", resource, "status", result)
+	driftRepairsTotal.WithLabelValues(resource).Inc()
+}
+
+// forgetResult drops the state remembered for an object the operator deleted
+func (r *ProjectReconciler) forgetResult(project *projects.Project, resource string, obj client.Object) {
+	r.appliedStates.Delete(appliedStateKey(project, resource, obj))
+}
+
+// forgetProject drops the states remembered for every object of a deleted
+// project
+func (r *ProjectReconciler) forgetProject(project *projects.Project) {
+	prefix := string(project.UID) + "/"
+	r.appliedStates.Range(func(key, _ interface{}) bool {
+		if strings.HasPrefix(key.(string), prefix) {
+			r.appliedStates.Delete(key)
+		}
+		return true
+	})
+}
+
+func appliedStateKey(project *projects.Project, resource string, obj client.Object) string {
+	return string(project.UID) + "/" + resource + "/" + obj.GetNamespace() + "/" + obj.GetName()
+}
+
+// appliedState hashes the parts of obj that the operator manages: everything
+// but its status and the metadata set by the API server
+func appliedState(obj client.Object) (string, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return "", err
+	}
+
+	metadata, _ := content["metadata"].(map[string]interface{})
+	content["metadata"] = map[string]interface{}{
+		"labels":          metadata["labels"],
+		"annotations":     metadata["annotations"],
+		"ownerReferences": metadata["ownerReferences"],
+	}
+	delete(content, "status")
+	delete(content, "apiVersion")
+	delete(content, "kind")
+
+	data, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+/*
+Unauthorized use, copying or distribution of any source code in this
+repository via any medium is strictly prohibited without the author's
+express written consent.
+
+ANY AUTHORIZED USE OF OR ACCESS TO THE SOFTWARE IS "AS IS", WITHOUT
+WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO
+THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+OF CONTRACT,TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+package controllers
+
+import (
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	"github.com/pivotal/projects-operator/pkg/keypolicy"
+)
+
+const (
+	managedLabelsAnnotation      = "projects.vmware.com/managed-labels"
+	managedAnnotationsAnnotation = "projects.vmware.com/managed-annotations"
+)
+
+func (r *ProjectReconciler) namespaceMetadataPolicy() keypolicy.Policy {
+	if r.NamespaceMetadataPolicy != nil {
+		return *r.NamespaceMetadataPolicy
+	}
+	return keypolicy.DefaultPolicy()
+}
+
+// desiredNamespaceMetadata returns the labels and annotations from the project
+// spec that are permitted by the policy, along with the keys it rejected. The
+// namespace labels of the project class are set by an administrator and so
+// are not subject to the policy.
+func (r *ProjectReconciler) desiredNamespaceMetadata(project *projects.Project, class *projects.ProjectClass) (map[string]string, map[string]string, []string) {
+	var labels, annotations map[string]string
+	var rejected []string
+
+	if project.Spec.NamespaceMetadata != nil {
+		policy := r.namespaceMetadataPolicy()
+		var rejectedLabels, rejectedAnnotations []string
+		labels, rejectedLabels = policy.Filter(project.Spec.NamespaceMetadata.Labels)
+		annotations, rejectedAnnotations = policy.Filter(project.Spec.NamespaceMetadata.Annotations)
+		rejected = append(rejectedLabels, rejectedAnnotations...)
+	}
+
+	if class != nil && len(class.Spec.NamespaceLabels) > 0 {
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		for key, value := range class.Spec.NamespaceLabels {
+			labels[key] = value
+		}
+	}
+
+	return labels, annotations, rejected
+}
+
+// applyManagedMetadata sets the desired labels and annotations on the namespace
+// and removes those it set previously that are no longer desired. Labels and
+// annotations added by anyone else are left in place.
+func applyManagedMetadata(namespace *corev1.Namespace, labels, annotations map[string]string) {
+	namespaceAnnotations := namespace.Annotations
+
+	namespace.Labels = applyManaged(namespace.Labels, labels, managedKeys(namespaceAnnotations, managedLabelsAnnotation))
+	namespaceAnnotations = applyManaged(namespaceAnnotations, annotations, managedKeys(namespaceAnnotations, managedAnnotationsAnnotation))
+
+	namespaceAnnotations = setManagedKeys(namespaceAnnotations, managedLabelsAnnotation, labels)
+	namespace.Annotations = setManagedKeys(namespaceAnnotations, managedAnnotationsAnnotation, annotations)
+}
+
+// releaseNamespaceMetadata removes the project label from the namespace, so
+// that nothing treats it as part of a project any more, along with the
+// annotations recording which labels and annotations the operator manages.
+// The other labels are kept, in particular the pod security labels, as the
+// workloads still running in a retained namespace must not be given more
+// privileges than they had.
+func releaseNamespaceMetadata(namespace *corev1.Namespace) {
+	delete(namespace.Labels, projects.ProjectLabel)
+	delete(namespace.Annotations, managedLabelsAnnotation)
+	delete(namespace.Annotations, managedAnnotationsAnnotation)
+}
+
+func applyManaged(current, desired map[string]string, previouslyManaged []string) map[string]string {
+	for _, key := range previouslyManaged {
+		if _, ok := desired[key]; !ok {
+			delete(current, key)
+		}
+	}
+
+	if len(desired) > 0 && current == nil {
+		current = make(map[string]string, len(desired))
+	}
+	for key, value := range desired {
+		current[key] = value
+	}
+
+	return current
+}
+
+func managedKeys(annotations map[string]string, annotation string) []string {
+	if annotations[annotation] == "" {
+		return nil
+	}
+	return strings.Split(annotations[annotation], ",")
+}
+
+func setManagedKeys(annotations map[string]string, annotation string, managed map[string]string) map[string]string {
+	if len(managed) == 0 {
+		delete(annotations, annotation)
+		return annotations
+	}
+
+	keys := make([]string, 0, len(managed))
+	for key := range managed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[annotation] = strings.Join(keys, ",")
+
+	return annotations
+}
@@ -0,0 +1,153 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+/*
+Unauthorized use, copying or distribution of any source code in this
+repository via any medium is strictly prohibited without the author's
+express written consent.
+
+ANY AUTHORIZED USE OF OR ACCESS TO THE SOFTWARE IS "AS IS", WITHOUT
+WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO
+THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+OF CONTRACT,TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+package controllers
+
+import (
+	"context"
+	"sort"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+)
+
+func (r *ProjectReconciler) isolated(project *projects.Project) bool {
+	if project.Spec.Network != nil && project.Spec.Network.Isolated != nil {
+		return *project.Spec.Network.Isolated
+	}
+	return r.NetworkIsolation
+}
+
+// createIsolationPolicy denies ingress to the project namespace from
+// everywhere but the namespace itself and the namespaces of its peer projects
+func (r *ProjectReconciler) createIsolationPolicy(ctx context.Context, project *projects.Project) error {
+	isolated := r.isolated(project)
+
+	var peers []string
+	if isolated {
+		var err error
+		if peers, err = r.networkPeers(ctx, project); err != nil {
+			return err
+		}
+	}
+
+	networkPolicy := &networkingv1.NetworkPolicy{ObjectMeta: projectObjectMeta(project, "isolation")}
+	return r.applyPolicy(ctx, project, networkPolicy, "networkpolicy", isolated, func() {
+		ingress := []networkingv1.NetworkPolicyIngressRule{{
+			From: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}},
+		}}
+
+		if len(peers) > 0 {
+			ingress = append(ingress, networkingv1.NetworkPolicyIngressRule{
+				From: []networkingv1.NetworkPolicyPeer{{
+					NamespaceSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{{
+							Key:      projects.ProjectLabel,
+							Operator: metav1.LabelSelectorOpIn,
+							Values:   peers,
+						}},
+					},
+				}},
+			})
+		}
+
+		networkPolicy.Spec = networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress:     ingress,
+		}
+	})
+}
+
+// networkPeers returns the sorted names of the projects allowed to connect to
+// the project
+func (r *ProjectReconciler) networkPeers(ctx context.Context, project *projects.Project) ([]string, error) {
+	if project.Spec.Network == nil {
+		return nil, nil
+	}
+
+	var projectList *projects.ProjectList
+	names := map[string]bool{}
+
+	for _, peer := range project.Spec.Network.AllowFrom {
+		if peer.ProjectName != "" {
+			names[peer.ProjectName] = true
+		}
+
+		if peer.ProjectSelector == nil {
+			continue
+		}
+
+		selector, err := metav1.LabelSelectorAsSelector(peer.ProjectSelector)
+		if err != nil {
+			return nil, err
+		}
+
+		if projectList == nil {
+			projectList = &projects.ProjectList{}
+			if err := r.Client.List(ctx, projectList); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, other := range projectList.Items {
+			if selector.Matches(labels.Set(other.Labels)) {
+				names[other.Name] = true
+			}
+		}
+	}
+	delete(names, project.Name)
+
+	peers := make([]string, 0, len(names))
+	for name := range names {
+		peers = append(peers, name)
+	}
+	sort.Strings(peers)
+
+	return peers, nil
+}
+
+// projectsSelectingPeers maps a project to the other projects that select
+// their peers by label, as a change to its labels may change their peers
+func (r *ProjectReconciler) projectsSelectingPeers(obj client.Object) []reconcile.Request {
+	projectList := &projects.ProjectList{}
+	if err := r.Client.List(context.Background(), projectList); err != nil {
+		r.Log.Error(err, "unable to list Projects", "project", obj.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, project := range projectList.Items {
+		if project.Name == obj.GetName() || project.Spec.Network == nil {
+			continue
+		}
+
+		for _, peer := range project.Spec.Network.AllowFrom {
+			if peer.ProjectSelector != nil {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: project.Name}})
+				break
+			}
+		}
+	}
+
+	return requests
+}
@@ -0,0 +1,192 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+/*
+Unauthorized use, copying or distribution of any source code in this
+repository via any medium is strictly prohibited without the author's
+express written consent.
+
+ANY AUTHORIZED USE OF OR ACCESS TO THE SOFTWARE IS "AS IS", WITHOUT
+WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO
+THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+OF CONTRACT,TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+)
+
+// projectClass returns the ProjectClass named by the project, or the default
+// class when it names none. It returns nil when there is no default class.
+func (r *ProjectReconciler) projectClass(ctx context.Context, project *projects.Project) (*projects.ProjectClass, error) {
+	if project.Spec.ProjectClassName != "" {
+		class := &projects.ProjectClass{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Name: project.Spec.ProjectClassName}, class); err != nil {
+			if errors.IsNotFound(err) {
+				return nil, fmt.Errorf("ProjectClass '%s' not found", project.Spec.ProjectClassName)
+			}
+			return nil, err
+		}
+		return class, nil
+	}
+
+	classes := &projects.ProjectClassList{}
+	if err := r.Client.List(ctx, classes); err != nil {
+		return nil, err
+	}
+
+	var defaults []*projects.ProjectClass
+	for i := range classes.Items {
+		if classes.Items[i].IsDefault() {
+			defaults = append(defaults, &classes.Items[i])
+		}
+	}
+
+	switch len(defaults) {
+	case 0:
+		return nil, nil
+	case 1:
+		return defaults[0], nil
+	default:
+		var names []string
+		for _, class := range defaults {
+			names = append(names, class.Name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("more than one default ProjectClass: %s", strings.Join(names, ", "))
+	}
+}
+
+// projectsForClass maps a ProjectClass to the projects that use it, so that
+// changes to the class are rolled out to them
+func (r *ProjectReconciler) projectsForClass(obj client.Object) []reconcile.Request {
+	class, ok := obj.(*projects.ProjectClass)
+	if !ok {
+		return nil
+	}
+
+	projectList := &projects.ProjectList{}
+	if err := r.Client.List(context.Background(), projectList); err != nil {
+		r.Log.Error(err, "unable to list Projects", "projectclass", class.Name)
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, project := range projectList.Items {
+		// a change to the default annotation can move projects without a
+		// class name between classes, so they are all reconciled
+		if project.Spec.ProjectClassName == class.Name || project.Spec.ProjectClassName == "" {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: project.Name}})
+		}
+	}
+
+	return requests
+}
+
+// createPolicies applies the ResourceQuota, LimitRange and NetworkPolicies of
+// the project to its namespace, removing any that are no longer defined. The
+// project's own quota and limits take precedence over those of its class.
+func (r *ProjectReconciler) createPolicies(ctx context.Context, project *projects.Project, class *projects.ProjectClass, status *projects.ProjectStatus) error {
+	var spec projects.ProjectClassSpec
+	if class != nil {
+		spec = class.Spec
+	}
+
+	quota := spec.ResourceQuota
+	if project.Spec.Quota != nil {
+		quota = project.Spec.Quota
+	}
+
+	limitRange := spec.LimitRange
+	if len(project.Spec.Limits) > 0 {
+		limitRange = &corev1.LimitRangeSpec{Limits: project.Spec.Limits}
+	}
+
+	resourceQuotaObj := &corev1.ResourceQuota{ObjectMeta: projectObjectMeta(project, "resourcequota")}
+	if err := r.applyPolicy(ctx, project, resourceQuotaObj, "resourcequota", quota != nil, func() {
+		resourceQuotaObj.Spec = *quota.DeepCopy()
+	}); err != nil {
+		return err
+	}
+
+	status.Quota = nil
+	if quota != nil {
+		status.Quota = resourceQuotaObj.Status.DeepCopy()
+	}
+
+	limitRangeObj := &corev1.LimitRange{ObjectMeta: projectObjectMeta(project, "limitrange")}
+	if err := r.applyPolicy(ctx, project, limitRangeObj, "limitrange", limitRange != nil, func() {
+		limitRangeObj.Spec = *limitRange.DeepCopy()
+	}); err != nil {
+		return err
+	}
+
+	networkPolicy := &networkingv1.NetworkPolicy{ObjectMeta: projectObjectMeta(project, "networkpolicy")}
+	if err := r.applyPolicy(ctx, project, networkPolicy, "networkpolicy", spec.NetworkPolicy != nil, func() {
+		networkPolicy.Spec = *spec.NetworkPolicy.DeepCopy()
+	}); err != nil {
+		return err
+	}
+
+	return r.createIsolationPolicy(ctx, project)
+}
+
+// applyPolicy creates or updates obj in the project namespace when it is
+// wanted and deletes it otherwise. Objects the project does not own are never
+// deleted.
+func (r *ProjectReconciler) applyPolicy(ctx context.Context, project *projects.Project, obj client.Object, resource string, wanted bool, mutate func()) error {
+	if !wanted {
+		if err := r.Client.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		if !isOwnedBy(obj, project) {
+			return nil
+		}
+		if err := r.Client.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		r.forgetResult(project, resource, obj)
+		r.Log.Info("deleting resource", "type", resource, "name", obj.GetName())
+		return nil
+	}
+
+	status, err := controllerutil.CreateOrUpdate(ctx, r.Client, obj, func() error {
+		if err := controllerutil.SetOwnerReference(project, obj, r.Scheme); err != nil {
+			return err
+		}
+		mutate()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	r.recordResult(project, resource, obj, status)
+
+	return nil
+}
+
+func projectObjectMeta(project *projects.Project, resource string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      project.Name + "-" + resource,
+		Namespace: project.Name,
+	}
+}
@@ -19,10 +19,15 @@ package controllers
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -32,12 +37,23 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	projects "github.com/pivotal/projects-operator/api/v1alpha1"
 	"github.com/pivotal/projects-operator/pkg/finalizer"
+	"github.com/pivotal/projects-operator/pkg/hierarchy"
+	"github.com/pivotal/projects-operator/pkg/keypolicy"
+	"github.com/pivotal/projects-operator/pkg/podsecurity"
 )
 
-const projectFinalizer = "project.finalizer.projects.vmware.com"
+const (
+	projectFinalizer = "project.finalizer.projects.vmware.com"
+
+	defaultNamespaceDeletionTimeout         = 10 * time.Minute
+	defaultNamespaceDeletionRequeueInterval = 5 * time.Second
+	failedNamespaceDeletionRequeueInterval  = time.Minute
+)
 
 type RoleConfiguration struct {
 	APIGroups []string
@@ -51,13 +67,44 @@ type ProjectReconciler struct {
 	Log            logr.Logger
 	Scheme         *runtime.Scheme
 	ClusterRoleRef rbacv1.RoleRef
+
+	// NetworkIsolation enables a default-deny ingress NetworkPolicy for
+	// projects that do not set spec.network.isolated
+	NetworkIsolation bool
+
+	// RoleProfiles are the roles that project subjects may request. Subjects
+	// without a role are bound to ClusterRoleRef as admins.
+	RoleProfiles []RoleProfile
+
+	// NamespaceDeletionTimeout is how long a project may wait for its
+	// namespace to be deleted before it is marked as Failed
+	NamespaceDeletionTimeout time.Duration
+	// NamespaceDeletionRequeueInterval is how often a terminating
+	// namespace is checked
+	NamespaceDeletionRequeueInterval time.Duration
+
+	// NamespaceMetadataPolicy restricts the label and annotation keys that
+	// may be propagated to project namespaces. The keypolicy default is used
+	// when it is nil.
+	NamespaceMetadataPolicy *keypolicy.Policy
+
+	// PodSecurity holds the default and maximum Pod Security Admission
+	// levels of project namespaces
+	PodSecurity podsecurity.Config
+
+	// appliedStates holds a hash of the last state applied to each project
+	// resource, to tell drift repairs from desired state changes
+	appliedStates sync.Map
 }
 
 // +kubebuilder:rbac:groups=projects.vmware.com,resources=projects,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=projects.vmware.com,resources=projects/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=projects.vmware.com,resources=projectclasses,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=namespaces,verbs=watch;list;create;get;update;patch;delete
-// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles;roles,verbs=watch;list;create;get;update;patch
-// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterrolebindings;rolebindings,verbs=watch;list;create;get;update;patch
+// +kubebuilder:rbac:groups="",resources=resourcequotas;limitranges,verbs=watch;list;create;get;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=watch;list;create;get;update;patch;delete
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles;roles,verbs=watch;list;create;get;update;patch;delete
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterrolebindings;rolebindings,verbs=watch;list;create;get;update;patch;delete
 
 func (r *ProjectReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := r.Log.WithValues("project", req.NamespacedName)
@@ -74,92 +121,248 @@ func (r *ProjectReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, err
 	}
 
+	status := project.Status.DeepCopy()
+
 	if !project.ObjectMeta.DeletionTimestamp.IsZero() {
-		err := r.deleteNamespace(ctx, project)
-		return ctrl.Result{}, err
+		switch project.Spec.DeletionPolicy {
+		case projects.DeletionPolicyRetain, projects.DeletionPolicyOrphan:
+			return ctrl.Result{}, r.retainNamespace(ctx, project)
+		default:
+			return r.deleteNamespace(ctx, project, status)
+		}
 	}
 
-	if err := r.createNamespace(ctx, project); err != nil {
-		return ctrl.Result{}, err
+	if status.Phase == "" {
+		setPending(project, status)
+		if err := r.updateStatus(ctx, project, status); err != nil {
+			return ctrl.Result{}, err
+		}
 	}
 
-	if err := r.createClusterRole(ctx, project); err != nil {
-		return ctrl.Result{}, err
+	class, err := r.projectClass(ctx, project)
+	if err != nil {
+		return ctrl.Result{}, r.failed(ctx, project, status, projects.Ready, "ProjectClassFailed", err)
+	}
+	status.ProjectClassName = ""
+	if class != nil {
+		status.ProjectClassName = class.Name
 	}
 
-	if err := r.createClusterRoleBinding(ctx, project); err != nil {
-		return ctrl.Result{}, err
+	rejectedKeys, err := r.createNamespace(ctx, project, class)
+	if err != nil {
+		reason := "NamespaceFailed"
+		if _, ok := err.(*namespaceConflictError); ok {
+			reason = "NamespaceConflict"
+		}
+		return ctrl.Result{}, r.failed(ctx, project, status, projects.NamespaceReady, reason, err)
+	}
+	if len(rejectedKeys) > 0 {
+		message := fmt.Sprintf("namespace metadata keys not permitted by policy were ignored: %s", strings.Join(rejectedKeys, ", "))
+		setCondition(project, status, projects.NamespaceReady, metav1.ConditionTrue, "NamespaceMetadataIgnored", message)
+	} else {
+		setCondition(project, status, projects.NamespaceReady, metav1.ConditionTrue, "NamespaceProvisioned", "")
+	}
+	status.Namespace = project.Name
+
+	grants, err := r.accessGrants(ctx, project)
+	if err != nil {
+		return ctrl.Result{}, r.failed(ctx, project, status, projects.RBACReady, "InvalidParent", err)
+	}
+
+	now := time.Now()
+	status.ExpiredAccess = expiredGrants(project.Spec.Access, now)
+
+	subjects, err := r.projectSubjects(hierarchy.Active(grants, now))
+	if err != nil {
+		return ctrl.Result{}, r.failed(ctx, project, status, projects.RBACReady, "UnknownRole", err)
+	}
+
+	if err := r.createClusterRole(ctx, project, clusterRoleName(project), adminVerbs); err != nil {
+		return ctrl.Result{}, r.failed(ctx, project, status, projects.RBACReady, "ClusterRoleFailed", err)
+	}
+
+	if err := r.createClusterRoleBinding(ctx, project, clusterRoleBindingName(project), clusterRoleName(project), subjects.admins); err != nil {
+		return ctrl.Result{}, r.failed(ctx, project, status, projects.RBACReady, "ClusterRoleBindingFailed", err)
 	}
 
-	if err := r.createRoleBinding(ctx, project); err != nil {
+	if err := r.createViewerClusterRole(ctx, project, subjects.viewers); err != nil {
+		return ctrl.Result{}, r.failed(ctx, project, status, projects.RBACReady, "ClusterRoleFailed", err)
+	}
+
+	if err := r.createRoleBindings(ctx, project, r.roleBindings(project, class, subjects)); err != nil {
+		return ctrl.Result{}, r.failed(ctx, project, status, projects.RBACReady, "RoleBindingFailed", err)
+	}
+	setCondition(project, status, projects.RBACReady, metav1.ConditionTrue, "RBACProvisioned", "")
+
+	if err := r.createPolicies(ctx, project, class, status); err != nil {
+		return ctrl.Result{}, r.failed(ctx, project, status, projects.PoliciesReady, "PoliciesFailed", err)
+	}
+	setCondition(project, status, projects.PoliciesReady, metav1.ConditionTrue, "PoliciesApplied", "")
+
+	if project.AdoptsExistingNamespace() {
+		if status.UnmanagedRoleBindings, err = r.unmanagedRoleBindings(ctx, project); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if err := r.addFinalizer(ctx, project); err != nil {
 		return ctrl.Result{}, err
 	}
 
-	err := r.addFinalizer(ctx, project)
+	setActive(project, status)
+	err = r.updateStatus(ctx, project, status)
 
-	return ctrl.Result{}, err
+	// the bindings are reconciled again when the next grant starts or expires
+	return ctrl.Result{RequeueAfter: nextGrantChange(grants, now)}, err
 }
 
 func (r *ProjectReconciler) SetupWithManager(mgr ctrl.Manager, maxConcurrentReconciles int) error {
+	// the generated resources carry a non-controller owner reference to
+	// their project, so changes to them are mapped back via that reference
+	ownedBy := &handler.EnqueueRequestForOwner{OwnerType: &projects.Project{}}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&projects.Project{}).
+		Watches(&source.Kind{Type: &corev1.Namespace{}}, ownedBy).
+		Watches(&source.Kind{Type: &rbacv1.ClusterRole{}}, ownedBy).
+		Watches(&source.Kind{Type: &rbacv1.ClusterRoleBinding{}}, ownedBy).
+		Watches(&source.Kind{Type: &rbacv1.RoleBinding{}}, ownedBy).
+		Watches(&source.Kind{Type: &corev1.ResourceQuota{}}, ownedBy).
+		Watches(&source.Kind{Type: &corev1.LimitRange{}}, ownedBy).
+		Watches(&source.Kind{Type: &networkingv1.NetworkPolicy{}}, ownedBy).
+		Watches(&source.Kind{Type: &projects.ProjectClass{}}, handler.EnqueueRequestsFromMapFunc(r.projectsForClass)).
+		Watches(&source.Kind{Type: &projects.Project{}}, handler.EnqueueRequestsFromMapFunc(r.projectsSelectingPeers)).
+		Watches(&source.Kind{Type: &projects.Project{}}, handler.EnqueueRequestsFromMapFunc(r.projectDescendants)).
 		WithOptions(controller.Options{MaxConcurrentReconciles: maxConcurrentReconciles}).
 		Complete(r)
 }
 
-func (r *ProjectReconciler) createNamespace(ctx context.Context, project *projects.Project) error {
+// createNamespace creates the project namespace and keeps the project's
+// namespace metadata applied to it. It returns any metadata keys that were
+// not permitted by the policy.
+func (r *ProjectReconciler) createNamespace(ctx context.Context, project *projects.Project, class *projects.ProjectClass) ([]string, error) {
+	// the project's own labels are only copied when the namespace is created
+	initialLabels, rejected := r.namespaceMetadataPolicy().Filter(project.Labels)
+
 	namespace := &corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:   project.Name,
-			Labels: project.Labels,
+			Labels: initialLabels,
 		},
 	}
 
-	if err := controllerutil.SetOwnerReference(project, namespace, r.Scheme); err != nil {
-		return err
+	labels, annotations, rejectedMetadata := r.desiredNamespaceMetadata(project, class)
+	rejected = append(rejected, rejectedMetadata...)
+
+	// network policies of other projects select the namespace by this label
+	if labels == nil {
+		labels = map[string]string{}
 	}
+	labels[projects.ProjectLabel] = project.Name
+
+	// pod security labels are set last so that no other metadata can lower them
+	for key, value := range r.PodSecurity.NamespaceLabels(project.Spec.PodSecurity) {
+		labels[key] = value
+	}
+
+	status, err := controllerutil.CreateOrUpdate(ctx, r.Client, namespace, func() error {
+		if namespace.ResourceVersion != "" && !isOwnedBy(namespace, project) && !project.AdoptsExistingNamespace() {
+			return &namespaceConflictError{name: namespace.Name}
+		}
 
-	status, err := controllerutil.CreateOrUpdate(ctx, r.Client, namespace, func() error { return nil })
+		if err := controllerutil.SetOwnerReference(project, namespace, r.Scheme); err != nil {
+			return err
+		}
+		applyManagedMetadata(namespace, labels, annotations)
+		return nil
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	r.Log.Info("creating/updating resource", "type", "namespace", "status", status)
+	r.recordResult(project, "namespace", namespace, status)
 
-	return nil
+	sort.Strings(rejected)
+	return rejected, nil
 }
 
-func (r *ProjectReconciler) deleteNamespace(ctx context.Context, project *projects.Project) error {
-	_ = r.Client.Delete(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: project.Name}})
-	for {
-		namespace := &corev1.Namespace{}
-		if err := r.Client.Get(ctx, types.NamespacedName{Name: project.Name}, namespace); err != nil {
-			if errors.IsNotFound(err) {
-				status, err := controllerutil.CreateOrUpdate(ctx, r.Client, project, func() error {
-					finalizer.RemoveFinalizer(project, projectFinalizer)
-					return nil
-				})
-				if err != nil {
-					return err
-				}
-				r.Log.Info("creating/updating resource", "type", "project", "status", status)
-				return nil
-			}
-			time.Sleep(time.Second)
+// deleteNamespace drives project deletion without blocking the worker. The
+// namespace delete is issued once and the request is requeued until the
+// namespace is gone, at which point the finalizer is removed.
+func (r *ProjectReconciler) deleteNamespace(ctx context.Context, project *projects.Project, status *projects.ProjectStatus) (ctrl.Result, error) {
+	if !finalizer.HasFinalizer(project, projectFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	namespace := &corev1.Namespace{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: project.Name}, namespace)
+	if err != nil && !errors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+
+	if err == nil && namespace.DeletionTimestamp.IsZero() {
+		if err := r.Client.Delete(ctx, namespace); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+		r.Log.Info("deleting resource", "type", "namespace", "name", namespace.Name)
+
+		// an empty namespace may be removed straight away
+		err = r.Client.Get(ctx, types.NamespacedName{Name: project.Name}, namespace)
+		if err != nil && !errors.IsNotFound(err) {
+			return ctrl.Result{}, err
 		}
 	}
+
+	if errors.IsNotFound(err) {
+		return ctrl.Result{}, r.removeFinalizer(ctx, project)
+	}
+
+	timeout := r.namespaceDeletionTimeout()
+	if time.Since(project.DeletionTimestamp.Time) > timeout {
+		status.Phase = projects.ProjectFailed
+		message := fmt.Sprintf("namespace '%s' was not deleted within %s", project.Name, timeout)
+		setCondition(project, status, projects.Ready, metav1.ConditionFalse, "NamespaceDeletionTimedOut", message)
+		if err := r.updateStatus(ctx, project, status); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: failedNamespaceDeletionRequeueInterval}, nil
+	}
+
+	setTerminating(project, status)
+	if err := r.updateStatus(ctx, project, status); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: r.namespaceDeletionRequeueInterval()}, nil
 }
 
-func (r *ProjectReconciler) createClusterRole(ctx context.Context, project *projects.Project) error {
+func (r *ProjectReconciler) namespaceDeletionTimeout() time.Duration {
+	if r.NamespaceDeletionTimeout > 0 {
+		return r.NamespaceDeletionTimeout
+	}
+	return defaultNamespaceDeletionTimeout
+}
+
+func (r *ProjectReconciler) namespaceDeletionRequeueInterval() time.Duration {
+	if r.NamespaceDeletionRequeueInterval > 0 {
+		return r.NamespaceDeletionRequeueInterval
+	}
+	return defaultNamespaceDeletionRequeueInterval
+}
+
+func (r *ProjectReconciler) createClusterRole(ctx context.Context, project *projects.Project, name string, verbs []string) error {
 	clusterRole := &rbacv1.ClusterRole{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: clusterRoleName(project),
+			Name: name,
 		},
 	}
-	if err := controllerutil.SetOwnerReference(project, clusterRole, r.Scheme); err != nil {
-		return err
-	}
-
 	status, err := controllerutil.CreateOrUpdate(ctx, r.Client, clusterRole, func() error {
+		if err := controllerutil.SetOwnerReference(project, clusterRole, r.Scheme); err != nil {
+			return err
+		}
 		clusterRole.Rules = []rbacv1.PolicyRule{
 			{
 				APIGroups: []string{
@@ -171,13 +374,7 @@ func (r *ProjectReconciler) createClusterRole(ctx context.Context, project *proj
 				ResourceNames: []string{
 					project.Name,
 				},
-				Verbs: []string{
-					"get",
-					"update",
-					"delete",
-					"patch",
-					"watch",
-				},
+				Verbs: verbs,
 			},
 		}
 		return nil
@@ -186,27 +383,26 @@ func (r *ProjectReconciler) createClusterRole(ctx context.Context, project *proj
 		return err
 	}
 
-	r.Log.Info("creating/updating resource", "type", "clusterrole", "status", status)
+	r.recordResult(project, "clusterrole", clusterRole, status)
 
 	return nil
 }
 
-func (r *ProjectReconciler) createClusterRoleBinding(ctx context.Context, project *projects.Project) error {
+func (r *ProjectReconciler) createClusterRoleBinding(ctx context.Context, project *projects.Project, name, clusterRoleName string, subjects []rbacv1.Subject) error {
 	clusterRoleBinding := &rbacv1.ClusterRoleBinding{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: project.Name + "-clusterrolebinding",
+			Name: name,
 		},
 	}
-	if err := controllerutil.SetOwnerReference(project, clusterRoleBinding, r.Scheme); err != nil {
-		return err
-	}
-
 	status, err := controllerutil.CreateOrUpdate(ctx, r.Client, clusterRoleBinding, func() error {
-		clusterRoleBinding.Subjects = subjects(project)
+		if err := controllerutil.SetOwnerReference(project, clusterRoleBinding, r.Scheme); err != nil {
+			return err
+		}
+		clusterRoleBinding.Subjects = subjects
 		clusterRoleBinding.RoleRef = rbacv1.RoleRef{
 			APIGroup: "rbac.authorization.k8s.io",
 			Kind:     "ClusterRole",
-			Name:     clusterRoleName(project),
+			Name:     clusterRoleName,
 		}
 		return nil
 	})
@@ -214,32 +410,80 @@ func (r *ProjectReconciler) createClusterRoleBinding(ctx context.Context, projec
 		return err
 	}
 
-	r.Log.Info("creating/updating resource", "type", "clusterrolebinding", "status", status)
+	r.recordResult(project, "clusterrolebinding", clusterRoleBinding, status)
 
 	return nil
 }
 
-func (r *ProjectReconciler) createRoleBinding(ctx context.Context, project *projects.Project) error {
-	roleBinding := &rbacv1.RoleBinding{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      project.Name + "-rolebinding",
-			Namespace: project.Name,
-		},
+// createViewerClusterRole gives read access to the project to subjects that
+// are not admins, and removes it again once there are none
+func (r *ProjectReconciler) createViewerClusterRole(ctx context.Context, project *projects.Project, viewers []rbacv1.Subject) error {
+	if len(viewers) == 0 {
+		clusterRoleBinding := &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: viewerClusterRoleBindingName(project)}}
+		clusterRole := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: viewerClusterRoleName(project)}}
+		for _, obj := range []client.Object{clusterRoleBinding, clusterRole} {
+			if err := r.Client.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+		r.forgetResult(project, "clusterrolebinding", clusterRoleBinding)
+		r.forgetResult(project, "clusterrole", clusterRole)
+		return nil
 	}
-	if err := controllerutil.SetOwnerReference(project, roleBinding, r.Scheme); err != nil {
+
+	if err := r.createClusterRole(ctx, project, viewerClusterRoleName(project), viewerVerbs); err != nil {
 		return err
 	}
 
-	status, err := controllerutil.CreateOrUpdate(ctx, r.Client, roleBinding, func() error {
-		roleBinding.Subjects = subjects(project)
-		roleBinding.RoleRef = r.ClusterRoleRef
-		return nil
-	})
-	if err != nil {
+	return r.createClusterRoleBinding(ctx, project, viewerClusterRoleBindingName(project), viewerClusterRoleName(project), viewers)
+}
+
+// createRoleBindings creates the given RoleBindings in the project namespace
+// and deletes any others owned by the project
+func (r *ProjectReconciler) createRoleBindings(ctx context.Context, project *projects.Project, bindings []desiredRoleBinding) error {
+	desired := map[string]bool{}
+
+	for _, binding := range bindings {
+		binding := binding
+		roleBinding := &rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      binding.name,
+				Namespace: project.Name,
+			},
+		}
+		status, err := controllerutil.CreateOrUpdate(ctx, r.Client, roleBinding, func() error {
+			if err := controllerutil.SetOwnerReference(project, roleBinding, r.Scheme); err != nil {
+				return err
+			}
+			roleBinding.Subjects = binding.subjects
+			roleBinding.RoleRef = binding.roleRef
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		r.recordResult(project, "rolebinding", roleBinding, status)
+		desired[roleBinding.Name] = true
+	}
+
+	roleBindings := &rbacv1.RoleBindingList{}
+	if err := r.Client.List(ctx, roleBindings, client.InNamespace(project.Name)); err != nil {
 		return err
 	}
 
-	r.Log.Info("creating/updating resource", "type", "rolebinding", "status", status)
+	for i := range roleBindings.Items {
+		roleBinding := &roleBindings.Items[i]
+		if desired[roleBinding.Name] || !isOwnedBy(roleBinding, project) {
+			continue
+		}
+
+		if err := r.Client.Delete(ctx, roleBinding); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		r.forgetResult(project, "rolebinding", roleBinding)
+		r.Log.Info("deleting resource", "type", "rolebinding", "name", roleBinding.Name)
+	}
 
 	return nil
 }
@@ -248,22 +492,65 @@ func clusterRoleName(project *projects.Project) string {
 	return project.Name + "-clusterrole"
 }
 
-func subjects(project *projects.Project) []rbacv1.Subject {
-	var subjects []rbacv1.Subject
-	for _, userRef := range project.Spec.Access {
+func clusterRoleBindingName(project *projects.Project) string {
+	return project.Name + "-clusterrolebinding"
+}
 
-		apiGroup := ""
-		if userRef.Kind == "User" || userRef.Kind == "Group" {
-			apiGroup = "rbac.authorization.k8s.io"
-		}
-		subjects = append(subjects, rbacv1.Subject{
-			Kind:      string(userRef.Kind),
-			Name:      userRef.Name,
-			Namespace: userRef.Namespace,
-			APIGroup:  apiGroup,
-		})
+func viewerClusterRoleName(project *projects.Project) string {
+	return project.Name + "-viewer-clusterrole"
+}
+
+func viewerClusterRoleBindingName(project *projects.Project) string {
+	return project.Name + "-viewer-clusterrolebinding"
+}
+
+func roleBindingName(project *projects.Project) string {
+	return project.Name + "-rolebinding"
+}
+
+func profileRoleBindingName(project *projects.Project, profile string) string {
+	if profile == "" {
+		return roleBindingName(project)
 	}
-	return subjects
+	return project.Name + "-" + profile + "-rolebinding"
+}
+
+func classRoleBindingName(project *projects.Project, clusterRole string) string {
+	return project.Name + "-class-" + clusterRole + "-rolebinding"
+}
+
+func subject(subjectRef projects.SubjectRef) rbacv1.Subject {
+	apiGroup := ""
+	if subjectRef.Kind == "User" || subjectRef.Kind == "Group" {
+		apiGroup = "rbac.authorization.k8s.io"
+	}
+
+	// service accounts without a namespace are those of the default
+	// namespace, as they are when listing the projects of a user
+	namespace := subjectRef.Namespace
+	if subjectRef.Kind == rbacv1.ServiceAccountKind && namespace == "" {
+		namespace = corev1.NamespaceDefault
+	}
+
+	return rbacv1.Subject{
+		Kind:      string(subjectRef.Kind),
+		Name:      subjectRef.Name,
+		Namespace: namespace,
+		APIGroup:  apiGroup,
+	}
+}
+
+func (r *ProjectReconciler) removeFinalizer(ctx context.Context, project *projects.Project) error {
+	status, err := controllerutil.CreateOrUpdate(ctx, r.Client, project, func() error {
+		finalizer.RemoveFinalizer(project, projectFinalizer)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	r.Log.Info("creating/updating resource", "type", "project", "status", status)
+	r.forgetProject(project)
+	return nil
 }
 
 func (r *ProjectReconciler) addFinalizer(ctx context.Context, project *projects.Project) error {
@@ -23,18 +23,24 @@ import (
 
 	projects "github.com/pivotal/projects-operator/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	. "github.com/pivotal/projects-operator/controllers"
+	"github.com/pivotal/projects-operator/pkg/keypolicy"
+	"github.com/pivotal/projects-operator/pkg/podsecurity"
 )
 
 var _ = Describe("ProjectController", func() {
@@ -57,6 +63,7 @@ var _ = Describe("ProjectController", func() {
 			projects.AddToScheme(scheme)
 			corev1.AddToScheme(scheme)
 			rbacv1.AddToScheme(scheme)
+			networkingv1.AddToScheme(scheme)
 
 			user1 = "some-user1"
 			user2 = "some-user2"
@@ -155,6 +162,27 @@ var _ = Describe("ProjectController", func() {
 				})
 			})
 
+			Describe("updates the project status", func() {
+				It("records the namespace, phase and conditions", func() {
+					_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+					Expect(err).NotTo(HaveOccurred())
+
+					updatedProject := &projects.Project{}
+					err = fakeClient.Get(ctx, client.ObjectKey{
+						Name: project.Name,
+					}, updatedProject)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(updatedProject.Status.Namespace).To(Equal("my-project"))
+					Expect(updatedProject.Status.Phase).To(Equal(projects.ProjectActive))
+					Expect(updatedProject.Status.ObservedGeneration).To(Equal(updatedProject.Generation))
+
+					Expect(meta.IsStatusConditionTrue(updatedProject.Status.Conditions, projects.NamespaceReady)).To(BeTrue())
+					Expect(meta.IsStatusConditionTrue(updatedProject.Status.Conditions, projects.RBACReady)).To(BeTrue())
+					Expect(meta.IsStatusConditionTrue(updatedProject.Status.Conditions, projects.Ready)).To(BeTrue())
+				})
+			})
+
 			Describe("creates a namespace", func() {
 				It("with given project name", func() {
 					_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
@@ -195,7 +223,187 @@ var _ = Describe("ProjectController", func() {
 					}, namespace)
 					Expect(err).NotTo(HaveOccurred())
 
-					Expect(namespace.Labels).To(Equal(labels))
+					for key, value := range labels {
+						Expect(namespace.Labels).To(HaveKeyWithValue(key, value))
+					}
+				})
+			})
+
+			Describe("namespace metadata", func() {
+				BeforeEach(func() {
+					project.Spec.NamespaceMetadata = &projects.NamespaceMetadata{
+						Labels:      map[string]string{"team.example.com/owner": "alice"},
+						Annotations: map[string]string{"team.example.com/contact": "alice@example.com"},
+					}
+
+					err := fakeClient.Update(ctx, project)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("applies the labels and annotations to the namespace", func() {
+					namespace := &corev1.Namespace{}
+					err := fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, namespace)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(namespace.Labels).To(HaveKeyWithValue("team.example.com/owner", "alice"))
+					Expect(namespace.Annotations).To(HaveKeyWithValue("team.example.com/contact", "alice@example.com"))
+				})
+
+				It("keeps the namespace in sync when the metadata changes", func() {
+					namespace := &corev1.Namespace{}
+					err := fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, namespace)
+					Expect(err).NotTo(HaveOccurred())
+					namespace.Labels["added-by-someone-else"] = "true"
+					err = fakeClient.Update(ctx, namespace)
+					Expect(err).NotTo(HaveOccurred())
+
+					reconciledProject := &projects.Project{}
+					err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, reconciledProject)
+					Expect(err).NotTo(HaveOccurred())
+					reconciledProject.Spec.NamespaceMetadata = &projects.NamespaceMetadata{
+						Labels: map[string]string{"team.example.com/cost-centre": "1234"},
+					}
+					err = fakeClient.Update(ctx, reconciledProject)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+					Expect(err).NotTo(HaveOccurred())
+
+					err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, namespace)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(namespace.Labels).To(HaveKeyWithValue("team.example.com/cost-centre", "1234"))
+					Expect(namespace.Labels).To(HaveKeyWithValue("added-by-someone-else", "true"))
+					Expect(namespace.Labels).NotTo(HaveKey("team.example.com/owner"))
+					Expect(namespace.Annotations).NotTo(HaveKey("team.example.com/contact"))
+				})
+
+				When("the metadata contains keys the policy does not permit", func() {
+					BeforeEach(func() {
+						reconciledProject := &projects.Project{}
+						err := fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, reconciledProject)
+						Expect(err).NotTo(HaveOccurred())
+						reconciledProject.Spec.NamespaceMetadata.Labels["pod-security.kubernetes.io/enforce"] = "privileged"
+						err = fakeClient.Update(ctx, reconciledProject)
+						Expect(err).NotTo(HaveOccurred())
+
+						_, err = reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+						Expect(err).NotTo(HaveOccurred())
+					})
+
+					It("ignores them and reports them in the NamespaceReady condition", func() {
+						namespace := &corev1.Namespace{}
+						err := fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, namespace)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(namespace.Labels).NotTo(HaveKey("pod-security.kubernetes.io/enforce"))
+
+						reconciledProject := &projects.Project{}
+						err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, reconciledProject)
+						Expect(err).NotTo(HaveOccurred())
+
+						condition := meta.FindStatusCondition(reconciledProject.Status.Conditions, projects.NamespaceReady)
+						Expect(condition).NotTo(BeNil())
+						Expect(condition.Reason).To(Equal("NamespaceMetadataIgnored"))
+						Expect(condition.Message).To(ContainSubstring("pod-security.kubernetes.io/enforce"))
+					})
+
+					When("the policy allows them", func() {
+						BeforeEach(func() {
+							reconciler.NamespaceMetadataPolicy = &keypolicy.Policy{
+								Allowed: []string{"pod-security.kubernetes.io/*", "team.example.com/*"},
+							}
+
+							_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+							Expect(err).NotTo(HaveOccurred())
+						})
+
+						It("applies them", func() {
+							namespace := &corev1.Namespace{}
+							err := fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, namespace)
+							Expect(err).NotTo(HaveOccurred())
+							Expect(namespace.Labels).To(HaveKeyWithValue("pod-security.kubernetes.io/enforce", "privileged"))
+						})
+					})
+				})
+			})
+
+			Describe("existing namespace", func() {
+				BeforeEach(func() {
+					namespace := &corev1.Namespace{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:   project.Name,
+							Labels: map[string]string{"legacy.org/team": "legacy"},
+						},
+					}
+					roleBinding := &rbacv1.RoleBinding{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "legacy-admins",
+							Namespace: project.Name,
+						},
+						RoleRef: rbacv1.RoleRef{Kind: "ClusterRole", Name: "admin"},
+					}
+					Expect(fakeClient.Create(ctx, namespace)).To(Succeed())
+					Expect(fakeClient.Create(ctx, roleBinding)).To(Succeed())
+				})
+
+				It("refuses to take over the namespace", func() {
+					_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+					Expect(err).To(MatchError("namespace 'my-project' already exists and is not owned by the project"))
+
+					reconciledProject := &projects.Project{}
+					err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, reconciledProject)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(reconciledProject.Status.Phase).To(Equal(projects.ProjectFailed))
+
+					condition := meta.FindStatusCondition(reconciledProject.Status.Conditions, projects.NamespaceReady)
+					Expect(condition).NotTo(BeNil())
+					Expect(condition.Reason).To(Equal("NamespaceConflict"))
+
+					namespace := &corev1.Namespace{}
+					err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, namespace)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(namespace.OwnerReferences).To(BeEmpty())
+				})
+
+				When("the project adopts it", func() {
+					BeforeEach(func() {
+						reconciledProject := &projects.Project{}
+						err := fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, reconciledProject)
+						Expect(err).NotTo(HaveOccurred())
+
+						reconciledProject.Annotations = map[string]string{projects.AdoptExistingNamespaceAnnotation: "true"}
+						Expect(fakeClient.Update(ctx, reconciledProject)).To(Succeed())
+					})
+
+					It("takes ownership of the namespace without recreating it", func() {
+						_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+						Expect(err).NotTo(HaveOccurred())
+
+						namespace := &corev1.Namespace{}
+						err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, namespace)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(namespace.Labels).To(HaveKeyWithValue("legacy.org/team", "legacy"))
+						Expect(namespace.OwnerReferences).To(HaveLen(1))
+						Expect(namespace.OwnerReferences[0].Kind).To(Equal("Project"))
+						Expect(namespace.OwnerReferences[0].Name).To(Equal(project.Name))
+					})
+
+					It("reports the pre-existing role bindings without deleting them", func() {
+						_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+						Expect(err).NotTo(HaveOccurred())
+
+						reconciledProject := &projects.Project{}
+						err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, reconciledProject)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(reconciledProject.Status.Phase).To(Equal(projects.ProjectActive))
+						Expect(reconciledProject.Status.UnmanagedRoleBindings).To(ConsistOf("legacy-admins"))
+
+						err = fakeClient.Get(ctx, client.ObjectKey{Name: "legacy-admins", Namespace: project.Name}, &rbacv1.RoleBinding{})
+						Expect(err).NotTo(HaveOccurred())
+					})
 				})
 			})
 
@@ -312,6 +520,38 @@ var _ = Describe("ProjectController", func() {
 					})
 				})
 
+				When("the subject is a ServiceAccount without a namespace", func() {
+					BeforeEach(func() {
+						project.Spec.Access = []projects.SubjectRef{
+							{
+								Kind: "ServiceAccount",
+								Name: "service-account",
+							},
+						}
+
+						err := fakeClient.Update(ctx, project)
+						Expect(err).NotTo(HaveOccurred())
+					})
+
+					It("binds the service account of the default namespace", func() {
+						_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+						Expect(err).NotTo(HaveOccurred())
+
+						role := &rbacv1.RoleBinding{}
+						err = fakeClient.Get(ctx, client.ObjectKey{
+							Name:      project.Name + "-rolebinding",
+							Namespace: project.Name,
+						}, role)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(role.Subjects).To(ConsistOf(rbacv1.Subject{
+							Kind:      "ServiceAccount",
+							Name:      "service-account",
+							Namespace: "default",
+						}))
+					})
+				})
+
 				When("the subject is a User", func() {
 					It("allows the user specified in the project access to the namespace", func() {
 						_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
@@ -461,115 +701,996 @@ var _ = Describe("ProjectController", func() {
 			})
 		})
 
-		Describe("update", func() {
-			Describe("update a role binding", func() {
-				It("that can be updated", func() {
-					_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
-					Expect(err).NotTo(HaveOccurred())
+		Describe("role profiles", func() {
+			var (
+				adminRoleRef rbacv1.RoleRef
+				viewRoleRef  rbacv1.RoleRef
+			)
 
-					// need to retrieve the reconciled project so that it has the correct ResourceVersion
-					reconciledProject := &projects.Project{}
-					err = fakeClient.Get(ctx, client.ObjectKey{
-						Namespace: project.Namespace,
-						Name:      project.Name,
-					}, reconciledProject)
-					Expect(err).NotTo(HaveOccurred())
+			BeforeEach(func() {
+				adminRoleRef = rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: "admin"}
+				viewRoleRef = rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: "view"}
 
-					first := reconciledProject.Spec.Access[0]
-					reconciledProject.Spec.Access = []projects.SubjectRef{first}
+				reconciler.RoleProfiles = []RoleProfile{
+					{Name: "admin", ClusterRoleRef: adminRoleRef, Admin: true},
+					{Name: "view", ClusterRoleRef: viewRoleRef},
+				}
 
-					err = fakeClient.Update(ctx, reconciledProject)
-					Expect(err).NotTo(HaveOccurred())
+				project.Spec.Access[0].Role = "admin"
+				project.Spec.Access[1].Role = "view"
+				Expect(fakeClient.Update(ctx, project)).To(Succeed())
+			})
 
-					_, err = reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
-					Expect(err).NotTo(HaveOccurred())
+			It("creates a role binding for each profile in use", func() {
+				_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
 
-					updatedRole := &rbacv1.RoleBinding{}
-					err = fakeClient.Get(ctx, client.ObjectKey{
-						Name:      project.Name + "-rolebinding",
-						Namespace: project.Name,
-					}, updatedRole)
-					Expect(err).NotTo(HaveOccurred())
+				adminBinding := &rbacv1.RoleBinding{}
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-admin-rolebinding", Namespace: project.Name}, adminBinding)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(adminBinding.RoleRef).To(Equal(adminRoleRef))
+				Expect(adminBinding.Subjects).To(HaveLen(1))
+				Expect(adminBinding.Subjects[0].Name).To(Equal(user1))
 
-					Expect(updatedRole.Subjects).To(HaveLen(1))
-					subject1 := updatedRole.Subjects[0]
-					Expect(subject1.Kind).To(Equal("User"))
-					Expect(subject1.Name).To(Equal(user1))
-					Expect(subject1.APIGroup).To(Equal("rbac.authorization.k8s.io"))
+				viewBinding := &rbacv1.RoleBinding{}
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-view-rolebinding", Namespace: project.Name}, viewBinding)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(viewBinding.RoleRef).To(Equal(viewRoleRef))
+				Expect(viewBinding.Subjects).To(HaveLen(1))
+				Expect(viewBinding.Subjects[0].Name).To(Equal(user2))
 
-					updatedClusterRole := &rbacv1.ClusterRoleBinding{}
-					err = fakeClient.Get(ctx, client.ObjectKey{
-						Name: project.Name + "-clusterrolebinding",
-					}, updatedClusterRole)
-					Expect(err).NotTo(HaveOccurred())
+				defaultBinding := &rbacv1.RoleBinding{}
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-rolebinding", Namespace: project.Name}, defaultBinding)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(defaultBinding.Subjects).To(BeEmpty())
+			})
 
-					Expect(updatedClusterRole.Subjects).To(HaveLen(1))
-					subject1 = updatedClusterRole.Subjects[0]
-					Expect(subject1.Kind).To(Equal("User"))
-					Expect(subject1.Name).To(Equal(user1))
-					Expect(subject1.APIGroup).To(Equal("rbac.authorization.k8s.io"))
-				})
+			It("grants write verbs on the project to admins only", func() {
+				_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
+
+				clusterRoleBinding := &rbacv1.ClusterRoleBinding{}
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-clusterrolebinding"}, clusterRoleBinding)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(clusterRoleBinding.Subjects).To(HaveLen(1))
+				Expect(clusterRoleBinding.Subjects[0].Name).To(Equal(user1))
+
+				viewerClusterRole := &rbacv1.ClusterRole{}
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-viewer-clusterrole"}, viewerClusterRole)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(viewerClusterRole.Rules).To(HaveLen(1))
+				Expect(viewerClusterRole.Rules[0].ResourceNames).To(Equal([]string{project.Name}))
+				Expect(viewerClusterRole.Rules[0].Verbs).To(Equal([]string{"get", "watch"}))
+
+				viewerClusterRoleBinding := &rbacv1.ClusterRoleBinding{}
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-viewer-clusterrolebinding"}, viewerClusterRoleBinding)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(viewerClusterRoleBinding.RoleRef.Name).To(Equal(project.Name + "-viewer-clusterrole"))
+				Expect(viewerClusterRoleBinding.Subjects).To(HaveLen(1))
+				Expect(viewerClusterRoleBinding.Subjects[0].Name).To(Equal(user2))
 			})
 
-			Describe("finalizer removal", func() {
-				It("deletes the namespace and removes the finalizer when a deletion timestamp is present", func() {
+			It("removes the bindings of profiles that are no longer used", func() {
+				_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
+
+				reconciledProject := &projects.Project{}
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, reconciledProject)
+				Expect(err).NotTo(HaveOccurred())
+
+				reconciledProject.Spec.Access[1].Role = "admin"
+				Expect(fakeClient.Update(ctx, reconciledProject)).To(Succeed())
+
+				_, err = reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
+
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-view-rolebinding", Namespace: project.Name}, &rbacv1.RoleBinding{})
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-viewer-clusterrole"}, &rbacv1.ClusterRole{})
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-viewer-clusterrolebinding"}, &rbacv1.ClusterRoleBinding{})
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+			})
+
+			When("a subject has an unknown role", func() {
+				BeforeEach(func() {
+					project.Spec.Access[1].Role = "unknown"
+					Expect(fakeClient.Update(ctx, project)).To(Succeed())
+				})
+
+				It("marks the project as failed", func() {
 					_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
-					Expect(err).NotTo(HaveOccurred())
+					Expect(err).To(MatchError("unknown role 'unknown' for User 'some-user2'"))
 
-					// need to retrieve the reconciled project so that it has the correct ResourceVersion
 					reconciledProject := &projects.Project{}
-					err = fakeClient.Get(ctx, client.ObjectKey{
-						Namespace: project.Namespace,
-						Name:      project.Name,
-					}, reconciledProject)
+					err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, reconciledProject)
 					Expect(err).NotTo(HaveOccurred())
 
-					Expect(reconciledProject.Finalizers).To(Not(BeEmpty()))
+					condition := meta.FindStatusCondition(reconciledProject.Status.Conditions, projects.RBACReady)
+					Expect(condition).NotTo(BeNil())
+					Expect(condition.Reason).To(Equal("UnknownRole"))
+				})
+			})
+		})
 
-					reconciledProject.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+		Describe("project class", func() {
+			var class *projects.ProjectClass
+
+			BeforeEach(func() {
+				class = &projects.ProjectClass{
+					ObjectMeta: metav1.ObjectMeta{Name: "restricted"},
+					Spec: projects.ProjectClassSpec{
+						ClusterRoles: []string{"edit", "monitoring"},
+						ResourceQuota: &corev1.ResourceQuotaSpec{
+							Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("10")},
+						},
+						LimitRange: &corev1.LimitRangeSpec{
+							Limits: []corev1.LimitRangeItem{{
+								Type:    corev1.LimitTypeContainer,
+								Default: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+							}},
+						},
+						NetworkPolicy: &networkingv1.NetworkPolicySpec{
+							PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+						},
+						NamespaceLabels: map[string]string{"tier.example.com/class": "restricted"},
+					},
+				}
+				Expect(fakeClient.Create(ctx, class)).To(Succeed())
+
+				project.Spec.ProjectClassName = class.Name
+				Expect(fakeClient.Update(ctx, project)).To(Succeed())
+			})
 
-					err = fakeClient.Update(ctx, reconciledProject)
-					Expect(err).NotTo(HaveOccurred())
+			It("binds the subjects to the ClusterRoles of the class", func() {
+				_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
 
-					_, err = reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				for _, clusterRole := range []string{"edit", "monitoring"} {
+					roleBinding := &rbacv1.RoleBinding{}
+					err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-class-" + clusterRole + "-rolebinding", Namespace: project.Name}, roleBinding)
 					Expect(err).NotTo(HaveOccurred())
+					Expect(roleBinding.RoleRef.Name).To(Equal(clusterRole))
+					Expect(roleBinding.Subjects).To(HaveLen(2))
+				}
 
-					namespace := &corev1.Namespace{}
-					err = fakeClient.Get(ctx, client.ObjectKey{
-						Name: project.Name,
-					}, namespace)
-					Expect(errors.IsNotFound(err)).To(BeTrue())
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-rolebinding", Namespace: project.Name}, &rbacv1.RoleBinding{})
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+			})
 
-					err = fakeClient.Get(ctx, client.ObjectKey{
-						Name: project.Name,
-					}, reconciledProject)
-					Expect(errors.IsNotFound(err)).To(BeTrue())
-				})
+			It("applies the policies and namespace labels of the class", func() {
+				_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
+
+				resourceQuota := &corev1.ResourceQuota{}
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-resourcequota", Namespace: project.Name}, resourceQuota)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resourceQuota.Spec.Hard.Pods().String()).To(Equal("10"))
+				Expect(resourceQuota.OwnerReferences).To(HaveLen(1))
+
+				limitRange := &corev1.LimitRange{}
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-limitrange", Namespace: project.Name}, limitRange)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(limitRange.Spec.Limits).To(HaveLen(1))
+
+				networkPolicy := &networkingv1.NetworkPolicy{}
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-networkpolicy", Namespace: project.Name}, networkPolicy)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(networkPolicy.Spec.PolicyTypes).To(ConsistOf(networkingv1.PolicyTypeIngress))
+
+				namespace := &corev1.Namespace{}
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, namespace)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(namespace.Labels).To(HaveKeyWithValue("tier.example.com/class", "restricted"))
+
+				reconciledProject := &projects.Project{}
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, reconciledProject)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reconciledProject.Status.ProjectClassName).To(Equal("restricted"))
+				Expect(meta.IsStatusConditionTrue(reconciledProject.Status.Conditions, projects.PoliciesReady)).To(BeTrue())
 			})
-		})
-	})
-})
 
-func Request(namespace, name string) ctrl.Request {
-	return ctrl.Request{
-		NamespacedName: types.NamespacedName{
-			Name: name,
-		},
-	}
-}
+			It("rolls out changes to the class", func() {
+				_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
 
-func Project(projectName string, labels map[string]string, users ...string) *projects.Project {
-	subjectRefs := []projects.SubjectRef{}
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: class.Name}, class)
+				Expect(err).NotTo(HaveOccurred())
+				class.Spec.LimitRange = nil
+				class.Spec.ResourceQuota.Hard = corev1.ResourceList{corev1.ResourcePods: resource.MustParse("20")}
+				Expect(fakeClient.Update(ctx, class)).To(Succeed())
 
-	for _, user := range users {
-		subjectRefs = append(subjectRefs, projects.SubjectRef{
-			Kind: "User",
-			Name: user,
-		})
-	}
+				_, err = reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
 
-	return &projects.Project{
-		ObjectMeta: metav1.ObjectMeta{
+				resourceQuota := &corev1.ResourceQuota{}
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-resourcequota", Namespace: project.Name}, resourceQuota)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resourceQuota.Spec.Hard.Pods().String()).To(Equal("20"))
+
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-limitrange", Namespace: project.Name}, &corev1.LimitRange{})
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+			})
+
+			It("does not count the rollout of a class change as a drift repair", func() {
+				_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
+				_, err = reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
+
+				repairsBefore := driftRepairs("resourcequota")
+
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: class.Name}, class)
+				Expect(err).NotTo(HaveOccurred())
+				class.Spec.ResourceQuota.Hard = corev1.ResourceList{corev1.ResourcePods: resource.MustParse("20")}
+				Expect(fakeClient.Update(ctx, class)).To(Succeed())
+
+				_, err = reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
+
+				resourceQuota := &corev1.ResourceQuota{}
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-resourcequota", Namespace: project.Name}, resourceQuota)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resourceQuota.Spec.Hard.Pods().String()).To(Equal("20"))
+				Expect(driftRepairs("resourcequota")).To(Equal(repairsBefore))
+			})
+
+			It("counts reverting a changed policy as a drift repair", func() {
+				_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
+
+				repairsBefore := driftRepairs("resourcequota")
+
+				resourceQuota := &corev1.ResourceQuota{}
+				key := client.ObjectKey{Name: project.Name + "-resourcequota", Namespace: project.Name}
+				Expect(fakeClient.Get(ctx, key, resourceQuota)).To(Succeed())
+				resourceQuota.Spec.Hard = corev1.ResourceList{corev1.ResourcePods: resource.MustParse("1000")}
+				Expect(fakeClient.Update(ctx, resourceQuota)).To(Succeed())
+
+				_, err = reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeClient.Get(ctx, key, resourceQuota)).To(Succeed())
+				Expect(resourceQuota.Spec.Hard.Pods().String()).To(Equal("10"))
+				Expect(driftRepairs("resourcequota")).To(Equal(repairsBefore + 1))
+			})
+
+			When("the project does not name a class", func() {
+				BeforeEach(func() {
+					project.Spec.ProjectClassName = ""
+					Expect(fakeClient.Update(ctx, project)).To(Succeed())
+				})
+
+				It("uses no class when there is no default", func() {
+					_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+					Expect(err).NotTo(HaveOccurred())
+
+					err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-resourcequota", Namespace: project.Name}, &corev1.ResourceQuota{})
+					Expect(errors.IsNotFound(err)).To(BeTrue())
+				})
+
+				It("uses the default class", func() {
+					class.Annotations = map[string]string{projects.DefaultProjectClassAnnotation: "true"}
+					Expect(fakeClient.Update(ctx, class)).To(Succeed())
+
+					_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+					Expect(err).NotTo(HaveOccurred())
+
+					err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-resourcequota", Namespace: project.Name}, &corev1.ResourceQuota{})
+					Expect(err).NotTo(HaveOccurred())
+				})
+			})
+
+			When("the class does not exist", func() {
+				BeforeEach(func() {
+					project.Spec.ProjectClassName = "missing"
+					Expect(fakeClient.Update(ctx, project)).To(Succeed())
+				})
+
+				It("marks the project as failed", func() {
+					_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+					Expect(err).To(MatchError("ProjectClass 'missing' not found"))
+
+					reconciledProject := &projects.Project{}
+					err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, reconciledProject)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(reconciledProject.Status.Phase).To(Equal(projects.ProjectFailed))
+				})
+			})
+		})
+
+		Describe("quota and limits", func() {
+			BeforeEach(func() {
+				project.Spec.Quota = &corev1.ResourceQuotaSpec{
+					Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("5")},
+				}
+				project.Spec.Limits = []corev1.LimitRangeItem{{
+					Type: corev1.LimitTypeContainer,
+					Max:  corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+				}}
+				Expect(fakeClient.Update(ctx, project)).To(Succeed())
+			})
+
+			It("creates a ResourceQuota and LimitRange in the namespace", func() {
+				_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
+
+				resourceQuota := &corev1.ResourceQuota{}
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-resourcequota", Namespace: project.Name}, resourceQuota)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resourceQuota.Spec.Hard.Pods().String()).To(Equal("5"))
+
+				limitRange := &corev1.LimitRange{}
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-limitrange", Namespace: project.Name}, limitRange)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(limitRange.Spec.Limits).To(Equal(project.Spec.Limits))
+			})
+
+			It("restores stripped owner references", func() {
+				_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
+
+				resourceQuota := &corev1.ResourceQuota{}
+				key := client.ObjectKey{Name: project.Name + "-resourcequota", Namespace: project.Name}
+				Expect(fakeClient.Get(ctx, key, resourceQuota)).To(Succeed())
+				resourceQuota.OwnerReferences = nil
+				Expect(fakeClient.Update(ctx, resourceQuota)).To(Succeed())
+
+				_, err = reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeClient.Get(ctx, key, resourceQuota)).To(Succeed())
+				Expect(resourceQuota.OwnerReferences).To(HaveLen(1))
+				Expect(resourceQuota.OwnerReferences[0].Name).To(Equal(project.Name))
+			})
+
+			It("takes precedence over the project class", func() {
+				class := &projects.ProjectClass{
+					ObjectMeta: metav1.ObjectMeta{Name: "default"},
+					Spec: projects.ProjectClassSpec{
+						ResourceQuota: &corev1.ResourceQuotaSpec{
+							Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("100")},
+						},
+					},
+				}
+				Expect(fakeClient.Create(ctx, class)).To(Succeed())
+
+				reconciledProject := &projects.Project{}
+				err := fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, reconciledProject)
+				Expect(err).NotTo(HaveOccurred())
+				reconciledProject.Spec.ProjectClassName = class.Name
+				Expect(fakeClient.Update(ctx, reconciledProject)).To(Succeed())
+
+				_, err = reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
+
+				resourceQuota := &corev1.ResourceQuota{}
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-resourcequota", Namespace: project.Name}, resourceQuota)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resourceQuota.Spec.Hard.Pods().String()).To(Equal("5"))
+			})
+
+			It("mirrors the quota usage into the project status", func() {
+				_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
+
+				resourceQuota := &corev1.ResourceQuota{}
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-resourcequota", Namespace: project.Name}, resourceQuota)
+				Expect(err).NotTo(HaveOccurred())
+				resourceQuota.Status = corev1.ResourceQuotaStatus{
+					Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("5")},
+					Used: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("3")},
+				}
+				Expect(fakeClient.Status().Update(ctx, resourceQuota)).To(Succeed())
+
+				_, err = reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
+
+				reconciledProject := &projects.Project{}
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, reconciledProject)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reconciledProject.Status.Quota).NotTo(BeNil())
+				Expect(reconciledProject.Status.Quota.Hard.Pods().String()).To(Equal("5"))
+				Expect(reconciledProject.Status.Quota.Used.Pods().String()).To(Equal("3"))
+			})
+		})
+
+		Describe("network isolation", func() {
+			isolationPolicy := func() (*networkingv1.NetworkPolicy, error) {
+				networkPolicy := &networkingv1.NetworkPolicy{}
+				err := fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-isolation", Namespace: project.Name}, networkPolicy)
+				return networkPolicy, err
+			}
+
+			It("labels the namespace with the project name", func() {
+				_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
+
+				namespace := &corev1.Namespace{}
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, namespace)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(namespace.Labels).To(HaveKeyWithValue(projects.ProjectLabel, project.Name))
+			})
+
+			It("does not isolate projects by default", func() {
+				_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = isolationPolicy()
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+			})
+
+			When("isolation is enabled", func() {
+				BeforeEach(func() {
+					reconciler.NetworkIsolation = true
+				})
+
+				It("only allows ingress from within the namespace", func() {
+					_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+					Expect(err).NotTo(HaveOccurred())
+
+					networkPolicy, err := isolationPolicy()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(networkPolicy.Spec.PolicyTypes).To(ConsistOf(networkingv1.PolicyTypeIngress))
+					Expect(networkPolicy.Spec.Ingress).To(HaveLen(1))
+					Expect(networkPolicy.Spec.Ingress[0].From).To(ConsistOf(networkingv1.NetworkPolicyPeer{PodSelector: &metav1.LabelSelector{}}))
+				})
+
+				It("allows ingress from the namespaces of the allowed projects", func() {
+					Expect(fakeClient.Create(ctx, Project("frontend", map[string]string{"tier": "web"}))).To(Succeed())
+					Expect(fakeClient.Create(ctx, Project("backend", map[string]string{"tier": "api"}))).To(Succeed())
+
+					project.Spec.Network = &projects.ProjectNetwork{
+						AllowFrom: []projects.ProjectPeer{
+							{ProjectName: "monitoring"},
+							{ProjectSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "web"}}},
+						},
+					}
+					Expect(fakeClient.Update(ctx, project)).To(Succeed())
+
+					_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+					Expect(err).NotTo(HaveOccurred())
+
+					networkPolicy, err := isolationPolicy()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(networkPolicy.Spec.Ingress).To(HaveLen(2))
+					Expect(networkPolicy.Spec.Ingress[1].From).To(HaveLen(1))
+					Expect(networkPolicy.Spec.Ingress[1].From[0].NamespaceSelector.MatchExpressions).To(ConsistOf(metav1.LabelSelectorRequirement{
+						Key:      projects.ProjectLabel,
+						Operator: metav1.LabelSelectorOpIn,
+						Values:   []string{"frontend", "monitoring"},
+					}))
+				})
+
+				It("can be turned off by the project", func() {
+					isolated := false
+					project.Spec.Network = &projects.ProjectNetwork{Isolated: &isolated}
+					Expect(fakeClient.Update(ctx, project)).To(Succeed())
+
+					_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = isolationPolicy()
+					Expect(errors.IsNotFound(err)).To(BeTrue())
+				})
+			})
+		})
+
+		Describe("pod security", func() {
+			namespaceLabels := func() map[string]string {
+				namespace := &corev1.Namespace{}
+				Expect(fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, namespace)).To(Succeed())
+				return namespace.Labels
+			}
+
+			It("does not label the namespace when no level is set", func() {
+				_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(namespaceLabels()).NotTo(HaveKey(podsecurity.EnforceLabel))
+			})
+
+			It("labels the namespace with the project levels", func() {
+				project.Spec.PodSecurity = &projects.PodSecurity{Enforce: projects.PodSecurityBaseline, Warn: projects.PodSecurityRestricted}
+				Expect(fakeClient.Update(ctx, project)).To(Succeed())
+
+				_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
+
+				labels := namespaceLabels()
+				Expect(labels).To(HaveKeyWithValue(podsecurity.EnforceLabel, "baseline"))
+				Expect(labels).To(HaveKeyWithValue(podsecurity.AuditLabel, "baseline"))
+				Expect(labels).To(HaveKeyWithValue(podsecurity.WarnLabel, "restricted"))
+			})
+
+			When("the operator sets a maximum", func() {
+				BeforeEach(func() {
+					reconciler.PodSecurity = podsecurity.Config{Default: projects.PodSecurityPrivileged, Maximum: projects.PodSecurityBaseline}
+				})
+
+				It("raises the levels to the maximum", func() {
+					_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(namespaceLabels()).To(HaveKeyWithValue(podsecurity.EnforceLabel, "baseline"))
+				})
+			})
+
+			It("restores labels that were lowered", func() {
+				reconciler.PodSecurity = podsecurity.Config{Default: projects.PodSecurityRestricted}
+				_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
+
+				namespace := &corev1.Namespace{}
+				Expect(fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, namespace)).To(Succeed())
+				namespace.Labels[podsecurity.EnforceLabel] = "privileged"
+				Expect(fakeClient.Update(ctx, namespace)).To(Succeed())
+
+				_, err = reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(namespaceLabels()).To(HaveKeyWithValue(podsecurity.EnforceLabel, "restricted"))
+			})
+		})
+
+		Describe("access expiry", func() {
+			var now time.Time
+
+			BeforeEach(func() {
+				now = time.Now()
+				project.Spec.Access[0].ExpiresAt = &metav1.Time{Time: now.Add(-time.Minute)}
+				project.Spec.Access[1].ExpiresAt = &metav1.Time{Time: now.Add(time.Hour)}
+				project.Spec.Access = append(project.Spec.Access, projects.SubjectRef{
+					Kind:      "User",
+					Name:      "on-call",
+					NotBefore: &metav1.Time{Time: now.Add(10 * time.Minute)},
+				})
+				Expect(fakeClient.Update(ctx, project)).To(Succeed())
+			})
+
+			It("only binds subjects whose access is current", func() {
+				_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
+
+				roleBinding := &rbacv1.RoleBinding{}
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-rolebinding", Namespace: project.Name}, roleBinding)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(roleBinding.Subjects).To(HaveLen(1))
+				Expect(roleBinding.Subjects[0].Name).To(Equal(user2))
+
+				clusterRoleBinding := &rbacv1.ClusterRoleBinding{}
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-clusterrolebinding"}, clusterRoleBinding)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(clusterRoleBinding.Subjects).To(HaveLen(1))
+				Expect(clusterRoleBinding.Subjects[0].Name).To(Equal(user2))
+			})
+
+			It("requeues when the next grant starts or expires", func() {
+				result, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(BeNumerically("~", 10*time.Minute, time.Minute))
+			})
+
+			It("lists the expired grants in the status", func() {
+				_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
+
+				reconciledProject := &projects.Project{}
+				Expect(fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, reconciledProject)).To(Succeed())
+				Expect(reconciledProject.Status.ExpiredAccess).To(HaveLen(1))
+				Expect(reconciledProject.Status.ExpiredAccess[0].Name).To(Equal(user1))
+			})
+		})
+
+		Describe("project hierarchy", func() {
+			BeforeEach(func() {
+				Expect(fakeClient.Create(ctx, Project("department", nil, "department-admin", user1))).To(Succeed())
+
+				project.Spec.Parent = "department"
+				Expect(fakeClient.Update(ctx, project)).To(Succeed())
+			})
+
+			It("binds the subjects inherited from the ancestors once", func() {
+				_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+				Expect(err).NotTo(HaveOccurred())
+
+				roleBinding := &rbacv1.RoleBinding{}
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-rolebinding", Namespace: project.Name}, roleBinding)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(roleBinding.Subjects).To(HaveLen(3))
+				Expect(roleBinding.Subjects[2].Name).To(Equal("department-admin"))
+
+				clusterRoleBinding := &rbacv1.ClusterRoleBinding{}
+				err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-clusterrolebinding"}, clusterRoleBinding)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(clusterRoleBinding.Subjects).To(HaveLen(3))
+			})
+
+			When("the hierarchy contains a cycle", func() {
+				BeforeEach(func() {
+					department := &projects.Project{}
+					Expect(fakeClient.Get(ctx, client.ObjectKey{Name: "department"}, department)).To(Succeed())
+					department.Spec.Parent = project.Name
+					Expect(fakeClient.Update(ctx, department)).To(Succeed())
+				})
+
+				It("marks the project as failed", func() {
+					_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+					Expect(err).To(MatchError("project hierarchy contains a cycle: " + project.Name + " -> department -> " + project.Name))
+
+					reconciledProject := &projects.Project{}
+					Expect(fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, reconciledProject)).To(Succeed())
+					condition := meta.FindStatusCondition(reconciledProject.Status.Conditions, projects.RBACReady)
+					Expect(condition).NotTo(BeNil())
+					Expect(condition.Reason).To(Equal("InvalidParent"))
+				})
+			})
+		})
+
+		Describe("update", func() {
+			Describe("update a role binding", func() {
+				It("that can be updated", func() {
+					_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+					Expect(err).NotTo(HaveOccurred())
+
+					// need to retrieve the reconciled project so that it has the correct ResourceVersion
+					reconciledProject := &projects.Project{}
+					err = fakeClient.Get(ctx, client.ObjectKey{
+						Namespace: project.Namespace,
+						Name:      project.Name,
+					}, reconciledProject)
+					Expect(err).NotTo(HaveOccurred())
+
+					first := reconciledProject.Spec.Access[0]
+					reconciledProject.Spec.Access = []projects.SubjectRef{first}
+
+					err = fakeClient.Update(ctx, reconciledProject)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+					Expect(err).NotTo(HaveOccurred())
+
+					updatedRole := &rbacv1.RoleBinding{}
+					err = fakeClient.Get(ctx, client.ObjectKey{
+						Name:      project.Name + "-rolebinding",
+						Namespace: project.Name,
+					}, updatedRole)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(updatedRole.Subjects).To(HaveLen(1))
+					subject1 := updatedRole.Subjects[0]
+					Expect(subject1.Kind).To(Equal("User"))
+					Expect(subject1.Name).To(Equal(user1))
+					Expect(subject1.APIGroup).To(Equal("rbac.authorization.k8s.io"))
+
+					updatedClusterRole := &rbacv1.ClusterRoleBinding{}
+					err = fakeClient.Get(ctx, client.ObjectKey{
+						Name: project.Name + "-clusterrolebinding",
+					}, updatedClusterRole)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(updatedClusterRole.Subjects).To(HaveLen(1))
+					subject1 = updatedClusterRole.Subjects[0]
+					Expect(subject1.Kind).To(Equal("User"))
+					Expect(subject1.Name).To(Equal(user1))
+					Expect(subject1.APIGroup).To(Equal("rbac.authorization.k8s.io"))
+				})
+			})
+
+			Describe("drift repair", func() {
+				It("recreates a deleted role binding and counts the repair", func() {
+					_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+					Expect(err).NotTo(HaveOccurred())
+
+					repairsBefore := driftRepairs("rolebinding")
+
+					err = fakeClient.Delete(ctx, &rbacv1.RoleBinding{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      project.Name + "-rolebinding",
+							Namespace: project.Name,
+						},
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+					Expect(err).NotTo(HaveOccurred())
+
+					role := &rbacv1.RoleBinding{}
+					err = fakeClient.Get(ctx, client.ObjectKey{
+						Name:      project.Name + "-rolebinding",
+						Namespace: project.Name,
+					}, role)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(driftRepairs("rolebinding")).To(Equal(repairsBefore + 1))
+				})
+
+				It("restores stripped owner references", func() {
+					_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+					Expect(err).NotTo(HaveOccurred())
+
+					owned := []client.Object{
+						&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: project.Name + "-clusterrole"}},
+						&rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: project.Name + "-clusterrolebinding"}},
+						&rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: project.Name + "-rolebinding", Namespace: project.Name}},
+					}
+					for _, obj := range owned {
+						Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+						obj.SetOwnerReferences(nil)
+						Expect(fakeClient.Update(ctx, obj)).To(Succeed())
+					}
+
+					_, err = reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+					Expect(err).NotTo(HaveOccurred())
+
+					for _, obj := range owned {
+						Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+						Expect(obj.GetOwnerReferences()).To(HaveLen(1), obj.GetName())
+						Expect(obj.GetOwnerReferences()[0].Name).To(Equal(project.Name))
+						Expect(obj.GetOwnerReferences()[0].Kind).To(Equal("Project"))
+					}
+				})
+
+				It("does not count the initial provisioning as a repair", func() {
+					repairsBefore := driftRepairs("namespace")
+
+					_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(driftRepairs("namespace")).To(Equal(repairsBefore))
+				})
+			})
+
+			Describe("finalizer removal", func() {
+				It("deletes the namespace and removes the finalizer when a deletion timestamp is present", func() {
+					_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+					Expect(err).NotTo(HaveOccurred())
+
+					// need to retrieve the reconciled project so that it has the correct ResourceVersion
+					reconciledProject := &projects.Project{}
+					err = fakeClient.Get(ctx, client.ObjectKey{
+						Namespace: project.Namespace,
+						Name:      project.Name,
+					}, reconciledProject)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(reconciledProject.Finalizers).To(Not(BeEmpty()))
+
+					reconciledProject.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+
+					err = fakeClient.Update(ctx, reconciledProject)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+					Expect(err).NotTo(HaveOccurred())
+
+					namespace := &corev1.Namespace{}
+					err = fakeClient.Get(ctx, client.ObjectKey{
+						Name: project.Name,
+					}, namespace)
+					Expect(errors.IsNotFound(err)).To(BeTrue())
+
+					err = fakeClient.Get(ctx, client.ObjectKey{
+						Name: project.Name,
+					}, reconciledProject)
+					Expect(errors.IsNotFound(err)).To(BeTrue())
+				})
+			})
+
+			Describe("namespace termination", func() {
+				var namespace *corev1.Namespace
+
+				BeforeEach(func() {
+					_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+					Expect(err).NotTo(HaveOccurred())
+
+					namespace = &corev1.Namespace{}
+					err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, namespace)
+					Expect(err).NotTo(HaveOccurred())
+
+					namespace.Finalizers = []string{"example.com/hold"}
+					err = fakeClient.Update(ctx, namespace)
+					Expect(err).NotTo(HaveOccurred())
+
+					reconciledProject := &projects.Project{}
+					err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, reconciledProject)
+					Expect(err).NotTo(HaveOccurred())
+
+					err = fakeClient.Delete(ctx, reconciledProject)
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				When("the namespace is still terminating", func() {
+					It("requeues without removing the finalizer", func() {
+						result, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+						Expect(err).NotTo(HaveOccurred())
+						Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+						reconciledProject := &projects.Project{}
+						err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, reconciledProject)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(reconciledProject.Finalizers).To(ConsistOf("project.finalizer.projects.vmware.com"))
+						Expect(reconciledProject.Status.Phase).To(Equal(projects.ProjectTerminating))
+
+						err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, namespace)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(namespace.DeletionTimestamp.IsZero()).To(BeFalse())
+					})
+
+					It("removes the finalizer once the namespace is gone", func() {
+						_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+						Expect(err).NotTo(HaveOccurred())
+
+						err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, namespace)
+						Expect(err).NotTo(HaveOccurred())
+						namespace.Finalizers = nil
+						err = fakeClient.Update(ctx, namespace)
+						Expect(err).NotTo(HaveOccurred())
+
+						result, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+						Expect(err).NotTo(HaveOccurred())
+						Expect(result.RequeueAfter).To(BeZero())
+
+						err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, &projects.Project{})
+						Expect(errors.IsNotFound(err)).To(BeTrue())
+					})
+				})
+
+				When("the namespace deletion times out", func() {
+					BeforeEach(func() {
+						reconciler.NamespaceDeletionTimeout = time.Nanosecond
+					})
+
+					It("marks the project as failed", func() {
+						_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+						Expect(err).NotTo(HaveOccurred())
+
+						reconciledProject := &projects.Project{}
+						err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, reconciledProject)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(reconciledProject.Finalizers).To(ConsistOf("project.finalizer.projects.vmware.com"))
+						Expect(reconciledProject.Status.Phase).To(Equal(projects.ProjectFailed))
+
+						condition := meta.FindStatusCondition(reconciledProject.Status.Conditions, projects.Ready)
+						Expect(condition).NotTo(BeNil())
+						Expect(condition.Reason).To(Equal("NamespaceDeletionTimedOut"))
+					})
+				})
+
+				When("the context has been cancelled", func() {
+					It("returns the context error", func() {
+						cancelledCtx, cancel := context.WithCancel(ctx)
+						cancel()
+
+						_, err := reconciler.Reconcile(cancelledCtx, Request(project.Namespace, project.Name))
+						Expect(err).To(MatchError(context.Canceled))
+					})
+				})
+			})
+
+			Describe("deletion policy", func() {
+				deleteProject := func(policy projects.DeletionPolicy) {
+					_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+					Expect(err).NotTo(HaveOccurred())
+
+					reconciledProject := &projects.Project{}
+					err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, reconciledProject)
+					Expect(err).NotTo(HaveOccurred())
+
+					reconciledProject.Spec.DeletionPolicy = policy
+					err = fakeClient.Update(ctx, reconciledProject)
+					Expect(err).NotTo(HaveOccurred())
+
+					err = fakeClient.Delete(ctx, reconciledProject)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+					Expect(err).NotTo(HaveOccurred())
+
+					err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, &projects.Project{})
+					Expect(errors.IsNotFound(err)).To(BeTrue())
+				}
+
+				DescribeTable("the labels of the released namespace",
+					func(policy projects.DeletionPolicy) {
+						reconciler.PodSecurity = podsecurity.Config{Default: projects.PodSecurityRestricted}
+
+						_, err := reconciler.Reconcile(ctx, Request(project.Namespace, project.Name))
+						Expect(err).NotTo(HaveOccurred())
+
+						namespace := &corev1.Namespace{}
+						Expect(fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, namespace)).To(Succeed())
+						Expect(namespace.Labels).To(HaveKey(podsecurity.EnforceLabel))
+						namespace.Labels["team"] = "a-team"
+						Expect(fakeClient.Update(ctx, namespace)).To(Succeed())
+
+						deleteProject(policy)
+
+						Expect(fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, namespace)).To(Succeed())
+						// only the project label is replaced, the pod security
+						// labels keep constraining the workloads left running
+						Expect(namespace.Labels).NotTo(HaveKey(projects.ProjectLabel))
+						Expect(namespace.Labels).To(HaveKeyWithValue(FormerProjectLabel, project.Name))
+						Expect(namespace.Labels).To(HaveKeyWithValue("team", "a-team"))
+						Expect(namespace.Labels).To(HaveKeyWithValue(podsecurity.EnforceLabel, string(projects.PodSecurityRestricted)))
+						Expect(namespace.Labels).To(HaveKeyWithValue(podsecurity.AuditLabel, string(projects.PodSecurityRestricted)))
+						Expect(namespace.Labels).To(HaveKeyWithValue(podsecurity.WarnLabel, string(projects.PodSecurityRestricted)))
+						Expect(namespace.Annotations).NotTo(HaveKey("projects.vmware.com/managed-labels"))
+					},
+					Entry("with the Retain policy", projects.DeletionPolicyRetain),
+					Entry("with the Orphan policy", projects.DeletionPolicyOrphan),
+				)
+
+				When("the policy is Retain", func() {
+					It("keeps the namespace and deletes the project RBAC", func() {
+						deleteProject(projects.DeletionPolicyRetain)
+
+						namespace := &corev1.Namespace{}
+						err := fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, namespace)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(namespace.DeletionTimestamp.IsZero()).To(BeTrue())
+						Expect(namespace.OwnerReferences).To(BeEmpty())
+						Expect(namespace.Labels).To(HaveKeyWithValue(FormerProjectLabel, project.Name))
+
+						err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-rolebinding", Namespace: project.Name}, &rbacv1.RoleBinding{})
+						Expect(errors.IsNotFound(err)).To(BeTrue())
+
+						err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-clusterrole"}, &rbacv1.ClusterRole{})
+						Expect(errors.IsNotFound(err)).To(BeTrue())
+
+						err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-clusterrolebinding"}, &rbacv1.ClusterRoleBinding{})
+						Expect(errors.IsNotFound(err)).To(BeTrue())
+					})
+				})
+
+				When("the policy is Orphan", func() {
+					It("keeps the namespace and the project RBAC", func() {
+						deleteProject(projects.DeletionPolicyOrphan)
+
+						namespace := &corev1.Namespace{}
+						err := fakeClient.Get(ctx, client.ObjectKey{Name: project.Name}, namespace)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(namespace.OwnerReferences).To(BeEmpty())
+						Expect(namespace.Labels).To(HaveKeyWithValue(FormerProjectLabel, project.Name))
+
+						roleBinding := &rbacv1.RoleBinding{}
+						err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-rolebinding", Namespace: project.Name}, roleBinding)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(roleBinding.OwnerReferences).To(BeEmpty())
+
+						clusterRole := &rbacv1.ClusterRole{}
+						err = fakeClient.Get(ctx, client.ObjectKey{Name: project.Name + "-clusterrole"}, clusterRole)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(clusterRole.OwnerReferences).To(BeEmpty())
+					})
+				})
+			})
+		})
+	})
+})
+
+func Request(namespace, name string) ctrl.Request {
+	return ctrl.Request{
+		NamespacedName: types.NamespacedName{
+			Name: name,
+		},
+	}
+}
+
+func Project(projectName string, labels map[string]string, users ...string) *projects.Project {
+	subjectRefs := []projects.SubjectRef{}
+
+	for _, user := range users {
+		subjectRefs = append(subjectRefs, projects.SubjectRef{
+			Kind: "User",
+			Name: user,
+		})
+	}
+
+	return &projects.Project{
+		ObjectMeta: metav1.ObjectMeta{
 			Name:   projectName,
 			Labels: labels,
 		},
@@ -578,3 +1699,23 @@ func Project(projectName string, labels map[string]string, users ...string) *pro
 		},
 	}
 }
+
+func driftRepairs(resource string) float64 {
+	families, err := metrics.Registry.Gather()
+	Expect(err).NotTo(HaveOccurred())
+
+	for _, family := range families {
+		if family.GetName() != "projects_operator_drift_repairs_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "resource" && label.GetValue() == resource {
+					return metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,83 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+/*
+Unauthorized use, copying or distribution of any source code in this
+repository via any medium is strictly prohibited without the author's
+express written consent.
+
+ANY AUTHORIZED USE OF OR ACCESS TO THE SOFTWARE IS "AS IS", WITHOUT
+WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO
+THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+OF CONTRACT,TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+)
+
+func setCondition(project *projects.Project, status *projects.ProjectStatus, conditionType string, conditionStatus metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             conditionStatus,
+		ObservedGeneration: project.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+func setPending(project *projects.Project, status *projects.ProjectStatus) {
+	status.Phase = projects.ProjectPending
+	setCondition(project, status, projects.Ready, metav1.ConditionUnknown, "Provisioning", "project resources are being provisioned")
+}
+
+func setActive(project *projects.Project, status *projects.ProjectStatus) {
+	status.Phase = projects.ProjectActive
+	status.ObservedGeneration = project.Generation
+	setCondition(project, status, projects.Ready, metav1.ConditionTrue, "Provisioned", "")
+}
+
+func setTerminating(project *projects.Project, status *projects.ProjectStatus) {
+	status.Phase = projects.ProjectTerminating
+	setCondition(project, status, projects.Ready, metav1.ConditionFalse, "Terminating", "project namespace is being deleted")
+}
+
+// failed records err against the given condition, marks the project as Failed
+// and returns err so that the request is retried
+func (r *ProjectReconciler) failed(ctx context.Context, project *projects.Project, status *projects.ProjectStatus, conditionType, reason string, err error) error {
+	status.Phase = projects.ProjectFailed
+	status.ObservedGeneration = project.Generation
+	setCondition(project, status, conditionType, metav1.ConditionFalse, reason, err.Error())
+	setCondition(project, status, projects.Ready, metav1.ConditionFalse, reason, err.Error())
+
+	if statusErr := r.updateStatus(ctx, project, status); statusErr != nil {
+		r.Log.Error(statusErr, "unable to update Project status", "project", project.Name)
+	}
+
+	return err
+}
+
+func (r *ProjectReconciler) updateStatus(ctx context.Context, project *projects.Project, status *projects.ProjectStatus) error {
+	if equality.Semantic.DeepEqual(project.Status, *status) {
+		return nil
+	}
+
+	project.Status = *status
+	if err := r.Client.Status().Update(ctx, project); err != nil {
+		return err
+	}
+	r.Log.Info("updated resource status", "type", "project", "phase", status.Phase)
+
+	return nil
+}
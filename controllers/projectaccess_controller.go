@@ -0,0 +1,192 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+/*
+Unauthorized use, copying or distribution of any source code in this
+repository via any medium is strictly prohibited without the author's
+express written consent.
+
+ANY AUTHORIZED USE OF OR ACCESS TO THE SOFTWARE IS "AS IS", WITHOUT
+WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO
+THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+OF CONTRACT,TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+)
+
+// ProjectAccessReconciler garbage collects ProjectAccesses and refreshes
+// those that ask for it
+type ProjectAccessReconciler struct {
+	client.Client
+	Log logr.Logger
+
+	// TTL is how long a ProjectAccess that is not refreshed is kept for. They
+	// are kept forever when it is zero.
+	TTL time.Duration
+
+	// RefreshInterval is how often refreshed ProjectAccesses are listed again
+	// even though no Project has changed, to pick up changes to the RBAC rules
+	// that grant access to projects. They are not when it is zero.
+	RefreshInterval time.Duration
+}
+
+// +kubebuilder:rbac:groups=projects.vmware.com,resources=projectaccesses,verbs=get;list;watch;update;delete
+
+func (r *ProjectAccessReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("projectaccess", req.NamespacedName)
+
+	projectAccess := &projects.ProjectAccess{}
+	if err := r.Client.Get(ctx, req.NamespacedName, projectAccess); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if projectAccess.Spec.Refresh {
+		return r.refresh(ctx, projectAccess)
+	}
+
+	if r.TTL == 0 {
+		return ctrl.Result{}, nil
+	}
+
+	if remaining := time.Until(projectAccess.CreationTimestamp.Add(r.TTL)); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	if err := r.Client.Delete(ctx, projectAccess); err != nil && !errors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	log.Info("deleted expired resource", "type", "projectaccess")
+
+	return ctrl.Result{}, nil
+}
+
+// refresh updates a ProjectAccess when the Projects have changed since its
+// status was listed, so that the webhook lists its projects again for the
+// user recorded in its status. It is requeued for the next grant that
+// starts or expires, and for the next refresh interval.
+func (r *ProjectAccessReconciler) refresh(ctx context.Context, projectAccess *projects.ProjectAccess) (ctrl.Result, error) {
+	if projectAccess.Status.User == "" {
+		r.Log.Info("cannot refresh resource without a recorded user", "type", "projectaccess", "name", projectAccess.Name)
+		return ctrl.Result{}, nil
+	}
+
+	projectList := &projects.ProjectList{}
+	if err := r.Client.List(ctx, projectList); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	now := time.Now()
+	result := ctrl.Result{RequeueAfter: r.nextRefresh(projectList.Items, now)}
+
+	fingerprint := projectsFingerprint(projectList.Items, now, r.RefreshInterval)
+	if projectAccess.Annotations[projects.ProjectAccessRefreshAnnotation] == fingerprint {
+		return result, nil
+	}
+
+	if projectAccess.Annotations == nil {
+		projectAccess.Annotations = map[string]string{}
+	}
+	projectAccess.Annotations[projects.ProjectAccessRefreshAnnotation] = fingerprint
+	if err := r.Client.Update(ctx, projectAccess); err != nil {
+		return ctrl.Result{}, err
+	}
+	r.Log.Info("refreshed resource", "type", "projectaccess", "name", projectAccess.Name)
+
+	return result, nil
+}
+
+// nextRefresh returns the time until the access to a project next changes
+// without a change to the projects, or zero if it does not
+func (r *ProjectAccessReconciler) nextRefresh(projectList []projects.Project, now time.Time) time.Duration {
+	var next time.Duration
+	if r.RefreshInterval > 0 {
+		next = now.Truncate(r.RefreshInterval).Add(r.RefreshInterval).Sub(now)
+	}
+	for _, project := range projectList {
+		if until := nextGrantChange(project.Spec.Access, now); until > 0 && (next == 0 || until < next) {
+			next = until
+		}
+	}
+
+	return next
+}
+
+// projectsFingerprint changes whenever a project is added or removed, changes
+// its spec or labels, or one of its grants starts or expires. Access inherited
+// from a parent changes with the spec of the parent. It also changes every
+// refresh interval, when that is not zero.
+func projectsFingerprint(projectList []projects.Project, now time.Time, refreshInterval time.Duration) string {
+	var entries []string
+	for _, project := range projectList {
+		var labels []string
+		for key, value := range project.Labels {
+			labels = append(labels, key+"="+value)
+		}
+		sort.Strings(labels)
+
+		var active []bool
+		for _, subject := range project.Spec.Access {
+			active = append(active, subject.ActiveAt(now))
+		}
+
+		entries = append(entries, fmt.Sprintf("%s/%d/%v/%v", project.Name, project.Generation, labels, active))
+	}
+	sort.Strings(entries)
+
+	hash := sha256.New()
+	if refreshInterval > 0 {
+		fmt.Fprintln(hash, now.Truncate(refreshInterval).UnixNano())
+	}
+	for _, entry := range entries {
+		fmt.Fprintln(hash, entry)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// refreshedProjectAccesses maps any change to a project to the
+// ProjectAccesses that are refreshed
+func (r *ProjectAccessReconciler) refreshedProjectAccesses(obj client.Object) []reconcile.Request {
+	projectAccessList := &projects.ProjectAccessList{}
+	if err := r.Client.List(context.Background(), projectAccessList); err != nil {
+		r.Log.Error(err, "unable to list ProjectAccesses", "project", obj.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, projectAccess := range projectAccessList.Items {
+		if projectAccess.Spec.Refresh {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: projectAccess.Name}})
+		}
+	}
+
+	return requests
+}
+
+func (r *ProjectAccessReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&projects.ProjectAccess{}).
+		Watches(&source.Kind{Type: &projects.Project{}}, handler.EnqueueRequestsFromMapFunc(r.refreshedProjectAccesses)).
+		Complete(r)
+}
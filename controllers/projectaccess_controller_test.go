@@ -0,0 +1,202 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+/*
+Unauthorized use, copying or distribution of any source code in this
+repository via any medium is strictly prohibited without the author's
+express written consent.
+
+ANY AUTHORIZED USE OF OR ACCESS TO THE SOFTWARE IS "AS IS", WITHOUT
+WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO
+THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+OF CONTRACT,TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/package controllers_test
+
+import (
+	"context"
+	"time"
+
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/pivotal/projects-operator/controllers"
+)
+
+var _ = Describe("ProjectAccessController", func() {
+	var (
+		reconciler    ProjectAccessReconciler
+		fakeClient    client.Client
+		projectAccess *projects.ProjectAccess
+		ctx           context.Context
+	)
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		projects.AddToScheme(scheme)
+
+		projectAccess = &projects.ProjectAccess{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "my-projectaccess",
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-2 * time.Hour)),
+			},
+			Status: projects.ProjectAccessStatus{User: "alice"},
+		}
+
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(projectAccess, Project("my-project", nil, "alice")).Build()
+		reconciler = ProjectAccessReconciler{
+			Log:    ctrl.Log.WithName("controllers").WithName("ProjectAccess"),
+			Client: fakeClient,
+			TTL:    time.Hour,
+		}
+		ctx = context.Background()
+	})
+
+	reconcile := func() ctrl.Result {
+		result, err := reconciler.Reconcile(ctx, Request("", projectAccess.Name))
+		Expect(err).NotTo(HaveOccurred())
+		return result
+	}
+
+	get := func() (*projects.ProjectAccess, error) {
+		updated := &projects.ProjectAccess{}
+		err := fakeClient.Get(ctx, client.ObjectKey{Name: projectAccess.Name}, updated)
+		return updated, err
+	}
+
+	It("deletes a ProjectAccess older than the TTL", func() {
+		reconcile()
+
+		_, err := get()
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("requeues a ProjectAccess until it is older than the TTL", func() {
+		reconciler.TTL = 3 * time.Hour
+
+		result := reconcile()
+		Expect(result.RequeueAfter).To(BeNumerically("~", time.Hour, time.Minute))
+
+		_, err := get()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("keeps ProjectAccesses without a TTL", func() {
+		reconciler.TTL = 0
+
+		Expect(reconcile()).To(Equal(ctrl.Result{}))
+
+		_, err := get()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("ignores ProjectAccesses that no longer exist", func() {
+		_, err := reconciler.Reconcile(ctx, Request("", "not-there"))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	When("the ProjectAccess is refreshed", func() {
+		BeforeEach(func() {
+			projectAccess, _ = get()
+			projectAccess.Spec.Refresh = true
+			Expect(fakeClient.Update(ctx, projectAccess)).To(Succeed())
+		})
+
+		It("is not deleted", func() {
+			reconcile()
+
+			_, err := get()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("is updated only when the projects change", func() {
+			reconcile()
+			refreshed, err := get()
+			Expect(err).NotTo(HaveOccurred())
+			fingerprint := refreshed.Annotations[projects.ProjectAccessRefreshAnnotation]
+			Expect(fingerprint).NotTo(BeEmpty())
+
+			reconcile()
+			unchanged, err := get()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(unchanged.ResourceVersion).To(Equal(refreshed.ResourceVersion))
+
+			Expect(fakeClient.Create(ctx, Project("other-project", nil, "bob"))).To(Succeed())
+			reconcile()
+			changed, err := get()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed.Annotations[projects.ProjectAccessRefreshAnnotation]).NotTo(Equal(fingerprint))
+		})
+
+		When("a grant expires", func() {
+			var project *projects.Project
+
+			BeforeEach(func() {
+				project = &projects.Project{}
+				Expect(fakeClient.Get(ctx, client.ObjectKey{Name: "my-project"}, project)).To(Succeed())
+				project.Spec.Access[0].ExpiresAt = &metav1.Time{Time: time.Now().Add(time.Hour)}
+				Expect(fakeClient.Update(ctx, project)).To(Succeed())
+			})
+
+			It("is requeued until the grant expires", func() {
+				Expect(reconcile().RequeueAfter).To(BeNumerically("~", time.Hour, time.Minute))
+			})
+
+			It("is updated once the grant has expired", func() {
+				reconcile()
+				refreshed, err := get()
+				Expect(err).NotTo(HaveOccurred())
+				fingerprint := refreshed.Annotations[projects.ProjectAccessRefreshAnnotation]
+
+				// the fake client keeps the generation, as the expiry does
+				project.Spec.Access[0].ExpiresAt = &metav1.Time{Time: time.Now().Add(-time.Minute)}
+				Expect(fakeClient.Update(ctx, project)).To(Succeed())
+
+				Expect(reconcile()).To(Equal(ctrl.Result{}))
+				expired, err := get()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(expired.Annotations[projects.ProjectAccessRefreshAnnotation]).NotTo(Equal(fingerprint))
+			})
+		})
+
+		When("a refresh interval is set", func() {
+			BeforeEach(func() {
+				reconciler.RefreshInterval = 100 * time.Millisecond
+			})
+
+			It("is updated every interval", func() {
+				result := reconcile()
+				Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+				Expect(result.RequeueAfter).To(BeNumerically("<=", 100*time.Millisecond))
+				refreshed, err := get()
+				Expect(err).NotTo(HaveOccurred())
+				fingerprint := refreshed.Annotations[projects.ProjectAccessRefreshAnnotation]
+
+				time.Sleep(result.RequeueAfter)
+				reconcile()
+				updated, err := get()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(updated.Annotations[projects.ProjectAccessRefreshAnnotation]).NotTo(Equal(fingerprint))
+			})
+		})
+
+		It("is not updated without a recorded user", func() {
+			projectAccess.Status.User = ""
+			Expect(fakeClient.Update(ctx, projectAccess)).To(Succeed())
+
+			reconcile()
+			updated, err := get()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated.Annotations).NotTo(HaveKey(projects.ProjectAccessRefreshAnnotation))
+		})
+	})
+})
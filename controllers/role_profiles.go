@@ -0,0 +1,144 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+/*
+Unauthorized use, copying or distribution of any source code in this
+repository via any medium is strictly prohibited without the author's
+express written consent.
+
+ANY AUTHORIZED USE OF OR ACCESS TO THE SOFTWARE IS "AS IS", WITHOUT
+WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO
+THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+OF CONTRACT,TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+package controllers
+
+import (
+	"fmt"
+	"sort"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+)
+
+var (
+	// adminVerbs are granted on the project to admin subjects
+	adminVerbs = []string{"get", "update", "delete", "patch", "watch"}
+	// viewerVerbs are granted on the project to all other subjects
+	viewerVerbs = []string{"get", "watch"}
+)
+
+// RoleProfile is a named role that project subjects can be given
+type RoleProfile struct {
+	// Name is matched against the role of a project subject
+	Name string
+	// ClusterRoleRef is bound to the profile's subjects in the project namespace
+	ClusterRoleRef rbacv1.RoleRef
+	// Admin profiles may update and delete the project itself, all other
+	// profiles may only read it
+	Admin bool
+}
+
+// projectSubjects are the subjects of a project grouped by what they are
+// granted
+type projectSubjects struct {
+	// admins and viewers are bound to the project ClusterRoles
+	admins  []rbacv1.Subject
+	viewers []rbacv1.Subject
+	// profiles maps each role profile in use to its subjects
+	profiles map[string][]rbacv1.Subject
+}
+
+// roleProfile returns the profile with the given name. Subjects without a
+// role use the default profile, which binds ClusterRoleRef with admin rights.
+func (r *ProjectReconciler) roleProfile(name string) (RoleProfile, bool) {
+	if name == "" {
+		return RoleProfile{ClusterRoleRef: r.ClusterRoleRef, Admin: true}, true
+	}
+
+	for _, profile := range r.RoleProfiles {
+		if profile.Name == name {
+			return profile, true
+		}
+	}
+
+	return RoleProfile{}, false
+}
+
+// projectSubjects groups the subjects in the effective access of a project
+func (r *ProjectReconciler) projectSubjects(access []projects.SubjectRef) (projectSubjects, error) {
+	// the default profile is always bound, even when no subject uses it
+	result := projectSubjects{profiles: map[string][]rbacv1.Subject{"": nil}}
+
+	for _, subjectRef := range access {
+		profile, ok := r.roleProfile(subjectRef.Role)
+		if !ok {
+			return projectSubjects{}, fmt.Errorf("unknown role '%s' for %s '%s'", subjectRef.Role, subjectRef.Kind, subjectRef.Name)
+		}
+
+		s := subject(subjectRef)
+		if profile.Admin {
+			result.admins = append(result.admins, s)
+		} else {
+			result.viewers = append(result.viewers, s)
+		}
+		result.profiles[profile.Name] = append(result.profiles[profile.Name], s)
+	}
+
+	return result, nil
+}
+
+// desiredRoleBinding is a RoleBinding the project needs in its namespace
+type desiredRoleBinding struct {
+	name     string
+	roleRef  rbacv1.RoleRef
+	subjects []rbacv1.Subject
+}
+
+// roleBindings returns a RoleBinding for each role profile in use. Subjects
+// without a role are bound to the ClusterRoles of the project class, when it
+// names any.
+func (r *ProjectReconciler) roleBindings(project *projects.Project, class *projects.ProjectClass, subjects projectSubjects) []desiredRoleBinding {
+	var bindings []desiredRoleBinding
+
+	for _, name := range subjects.profileNames() {
+		if name == "" && class != nil && len(class.Spec.ClusterRoles) > 0 {
+			for _, clusterRole := range class.Spec.ClusterRoles {
+				bindings = append(bindings, desiredRoleBinding{
+					name: classRoleBindingName(project, clusterRole),
+					roleRef: rbacv1.RoleRef{
+						APIGroup: "rbac.authorization.k8s.io",
+						Kind:     "ClusterRole",
+						Name:     clusterRole,
+					},
+					subjects: subjects.profiles[name],
+				})
+			}
+			continue
+		}
+
+		profile, _ := r.roleProfile(name)
+		bindings = append(bindings, desiredRoleBinding{
+			name:     profileRoleBindingName(project, name),
+			roleRef:  profile.ClusterRoleRef,
+			subjects: subjects.profiles[name],
+		})
+	}
+
+	return bindings
+}
+
+// profileNames returns the profiles in use in a stable order
+func (s projectSubjects) profileNames() []string {
+	var names []string
+	for name := range s.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
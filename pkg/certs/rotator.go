@@ -0,0 +1,326 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package certs
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// CAKey and CACertKey hold the key of the current CA and the bundle of
+	// every CA that is still trusted, newest first
+	CAKey     = "ca.key"
+	CACertKey = "ca.crt"
+)
+
+var apiServiceGVK = schema.GroupVersionKind{Group: "apiregistration.k8s.io", Version: "v1", Kind: "APIService"}
+
+// Options configure where a Rotator keeps its certificates and who it tells
+// about its CA
+type Options struct {
+	// Namespace and SecretName locate the Secret holding the certificates
+	Namespace  string
+	SecretName string
+
+	// DNSNames are the names the serving certificate is valid for
+	DNSNames []string
+
+	// MutatingWebhookConfigurations, ValidatingWebhookConfigurations and
+	// APIServices are the names of the resources whose caBundle is set to
+	// the CAs in the Secret
+	MutatingWebhookConfigurations   []string
+	ValidatingWebhookConfigurations []string
+	APIServices                     []string
+
+	// CAValidity and CertValidity are the lifetimes of new CA and serving
+	// certificates, which are renewed when a third of their lifetime is left
+	CAValidity   time.Duration
+	CertValidity time.Duration
+
+	// Interval is the time between checks of the certificates
+	Interval time.Duration
+
+	// Now returns the current time, time.Now when nil
+	Now func() time.Time
+}
+
+// Rotator creates, stores and rotates the CA and serving certificate of the
+// webhook and serves the current certificate to its TLS listeners
+type Rotator struct {
+	client  client.Client
+	logger  logr.Logger
+	options Options
+
+	certificate atomic.Value
+}
+
+func NewRotator(logger logr.Logger, client client.Client, options Options) *Rotator {
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+
+	return &Rotator{
+		client:  client,
+		logger:  logger,
+		options: options,
+	}
+}
+
+// GetCertificate returns the current serving certificate, for use in
+// tls.Config
+func (r *Rotator) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	certificate, ok := r.certificate.Load().(*tls.Certificate)
+	if !ok {
+		return nil, errors.New("no serving certificate has been loaded")
+	}
+	return certificate, nil
+}
+
+// Start checks the certificates every interval until the context is done
+func (r *Rotator) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := r.Sync(ctx); err != nil {
+			r.logger.Error(err, "failed to rotate webhook certificates")
+		}
+	}, r.options.Interval)
+
+	return nil
+}
+
+// Sync renews the CA and serving certificate when they are missing or about
+// to expire, stores them, sets the caBundle of the webhook configurations and
+// API services and loads the serving certificate.
+//
+// A new CA is added to the bundle next to the CAs that are still valid, and the
+// serving certificate is only signed by it on a later pass, once the API server
+// had the time to pick up the new bundle, unless there is no usable serving
+// certificate at all.
+func (r *Rotator) Sync(ctx context.Context) error {
+	now := r.options.Now()
+
+	secret := &corev1.Secret{}
+	err := r.client.Get(ctx, client.ObjectKey{Namespace: r.options.Namespace, Name: r.options.SecretName}, secret)
+	if apierrors.IsNotFound(err) {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: r.options.Namespace, Name: r.options.SecretName},
+			Type:       corev1.SecretTypeTLS,
+		}
+	} else if err != nil {
+		return fmt.Errorf("fetching certificate secret: %w", err)
+	}
+
+	data := map[string][]byte{}
+	for key, value := range secret.Data {
+		data[key] = value
+	}
+
+	// 1. Keep the CAs that are still valid and renew the current one
+	var bundle []*x509.Certificate
+	for _, cert := range parseCertificates(data[CACertKey]) {
+		if now.Before(cert.NotAfter) {
+			bundle = append(bundle, cert)
+		}
+	}
+
+	var ca *x509.Certificate
+	caKey, err := parseKey(data[CAKey])
+	if err == nil && len(bundle) > 0 && matches(bundle[0], caKey) {
+		ca = bundle[0]
+	}
+
+	rotatedCA := false
+	if ca == nil || needsRenewal(ca, now) {
+		newCA, newKey, err := newCA(now, r.options.CAValidity)
+		if err != nil {
+			return fmt.Errorf("creating CA: %w", err)
+		}
+		if data[CAKey], err = encodeKey(newKey); err != nil {
+			return fmt.Errorf("encoding CA key: %w", err)
+		}
+
+		ca, caKey = newCA, newKey
+		bundle = append([]*x509.Certificate{newCA}, bundle...)
+		rotatedCA = true
+		r.logger.Info("created webhook CA", "notAfter", newCA.NotAfter)
+	}
+	data[CACertKey] = encodeCertificates(bundle...)
+
+	// 2. Renew the serving certificate
+	var serving *x509.Certificate
+	if keyPair, err := tls.X509KeyPair(data[corev1.TLSCertKey], data[corev1.TLSPrivateKeyKey]); err == nil {
+		serving, _ = x509.ParseCertificate(keyPair.Certificate[0])
+	}
+
+	usable := serving != nil && now.Before(serving.NotAfter) && covers(serving, r.options.DNSNames) && trusted(serving, bundle)
+	if !usable || (!rotatedCA && (needsRenewal(serving, now) || serving.CheckSignatureFrom(ca) != nil)) {
+		cert, key, err := newServingCert(ca, caKey, r.options.DNSNames, now, r.options.CertValidity)
+		if err != nil {
+			return fmt.Errorf("creating serving certificate: %w", err)
+		}
+		if data[corev1.TLSPrivateKeyKey], err = encodeKey(key); err != nil {
+			return fmt.Errorf("encoding serving key: %w", err)
+		}
+		data[corev1.TLSCertKey] = encodeCertificates(cert)
+		r.logger.Info("created webhook serving certificate", "notAfter", cert.NotAfter)
+	}
+
+	// 3. Store the certificates
+	if err := r.store(ctx, secret, data); err != nil {
+		return err
+	}
+
+	// 4. Tell the API server about the CAs
+	if err := r.injectCABundle(ctx, data[CACertKey]); err != nil {
+		return err
+	}
+
+	// 5. Serve the certificate
+	keyPair, err := tls.X509KeyPair(data[corev1.TLSCertKey], data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return fmt.Errorf("loading serving certificate: %w", err)
+	}
+	r.certificate.Store(&keyPair)
+
+	return nil
+}
+
+func (r *Rotator) store(ctx context.Context, secret *corev1.Secret, data map[string][]byte) error {
+	if secret.ResourceVersion == "" {
+		secret.Data = data
+		if err := r.client.Create(ctx, secret); err != nil {
+			return fmt.Errorf("creating certificate secret: %w", err)
+		}
+		return nil
+	}
+
+	if equalData(secret.Data, data) {
+		return nil
+	}
+
+	secret.Data = data
+	if err := r.client.Update(ctx, secret); err != nil {
+		return fmt.Errorf("updating certificate secret: %w", err)
+	}
+	return nil
+}
+
+// injectCABundle sets the caBundle of every webhook of the configured webhook
+// configurations and of the configured API services. Resources that do not
+// exist yet are skipped until the next pass.
+func (r *Rotator) injectCABundle(ctx context.Context, caBundle []byte) error {
+	var errs []error
+
+	for _, name := range r.options.MutatingWebhookConfigurations {
+		configuration := &admissionregistrationv1.MutatingWebhookConfiguration{}
+		errs = append(errs, r.patch(ctx, name, configuration, func() bool {
+			changed := false
+			for i := range configuration.Webhooks {
+				if !bytes.Equal(configuration.Webhooks[i].ClientConfig.CABundle, caBundle) {
+					configuration.Webhooks[i].ClientConfig.CABundle = caBundle
+					changed = true
+				}
+			}
+			return changed
+		}))
+	}
+
+	for _, name := range r.options.ValidatingWebhookConfigurations {
+		configuration := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+		errs = append(errs, r.patch(ctx, name, configuration, func() bool {
+			changed := false
+			for i := range configuration.Webhooks {
+				if !bytes.Equal(configuration.Webhooks[i].ClientConfig.CABundle, caBundle) {
+					configuration.Webhooks[i].ClientConfig.CABundle = caBundle
+					changed = true
+				}
+			}
+			return changed
+		}))
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(caBundle)
+	for _, name := range r.options.APIServices {
+		apiService := &unstructured.Unstructured{}
+		apiService.SetGroupVersionKind(apiServiceGVK)
+		errs = append(errs, r.patch(ctx, name, apiService, func() bool {
+			if current, _, _ := unstructured.NestedString(apiService.Object, "spec", "caBundle"); current == encoded {
+				return false
+			}
+			_ = unstructured.SetNestedField(apiService.Object, encoded, "spec", "caBundle")
+			return true
+		}))
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// patch fetches the cluster scoped object and updates it when mutate
+// changed it
+func (r *Rotator) patch(ctx context.Context, name string, obj client.Object, mutate func() bool) error {
+	if err := r.client.Get(ctx, client.ObjectKey{Name: name}, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.logger.Info("cannot set caBundle of missing resource", "name", name)
+			return nil
+		}
+		return fmt.Errorf("fetching %s: %w", name, err)
+	}
+
+	if !mutate() {
+		return nil
+	}
+
+	if err := r.client.Update(ctx, obj); err != nil {
+		return fmt.Errorf("setting caBundle of %s: %w", name, err)
+	}
+	r.logger.Info("set caBundle", "name", name)
+
+	return nil
+}
+
+func matches(cert *x509.Certificate, key crypto.Signer) bool {
+	public, ok := cert.PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && public.Equal(key.Public())
+}
+
+// trusted reports whether the certificate is signed by one of the CAs
+func trusted(cert *x509.Certificate, bundle []*x509.Certificate) bool {
+	for _, ca := range bundle {
+		if cert.CheckSignatureFrom(ca) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func equalData(a, b map[string][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, ok := b[key]; !ok || !bytes.Equal(value, other) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,242 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package certs_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"time"
+
+	"github.com/go-logr/logr"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/pivotal/projects-operator/pkg/certs"
+)
+
+var _ = Describe("Rotator", func() {
+	const (
+		dnsName      = "projects-operator-webhook.projects-operator.svc"
+		caValidity   = 300 * 24 * time.Hour
+		certValidity = 30 * 24 * time.Hour
+	)
+
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		now        time.Time
+		rotator    *Rotator
+		secretKey  = client.ObjectKey{Namespace: "projects-operator", Name: "webhook-cert"}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		apiService := &unstructured.Unstructured{}
+		apiService.SetAPIVersion("apiregistration.k8s.io/v1")
+		apiService.SetKind("APIService")
+		apiService.SetName("v1alpha1.user.projects.vmware.com")
+		Expect(unstructured.SetNestedField(apiService.Object, "user.projects.vmware.com", "spec", "group")).To(Succeed())
+
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		scheme.AddKnownTypeWithName(apiService.GroupVersionKind(), &unstructured.Unstructured{})
+
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&admissionregistrationv1.MutatingWebhookConfiguration{
+				ObjectMeta: metav1.ObjectMeta{Name: "mutating"},
+				Webhooks:   []admissionregistrationv1.MutatingWebhook{{Name: "a.projects.vmware.com"}, {Name: "b.projects.vmware.com"}},
+			},
+			&admissionregistrationv1.ValidatingWebhookConfiguration{
+				ObjectMeta: metav1.ObjectMeta{Name: "validating"},
+				Webhooks:   []admissionregistrationv1.ValidatingWebhook{{Name: "c.projects.vmware.com"}},
+			},
+			apiService,
+		).Build()
+
+		rotator = NewRotator(logr.Discard(), fakeClient, Options{
+			Namespace:                       secretKey.Namespace,
+			SecretName:                      secretKey.Name,
+			DNSNames:                        []string{dnsName},
+			MutatingWebhookConfigurations:   []string{"mutating", "missing"},
+			ValidatingWebhookConfigurations: []string{"validating"},
+			APIServices:                     []string{"v1alpha1.user.projects.vmware.com"},
+			CAValidity:                      caValidity,
+			CertValidity:                    certValidity,
+			Now:                             func() time.Time { return now },
+		})
+	})
+
+	secret := func() *corev1.Secret {
+		secret := &corev1.Secret{}
+		Expect(fakeClient.Get(ctx, secretKey, secret)).To(Succeed())
+		return secret
+	}
+
+	certificates := func(data []byte) []*x509.Certificate {
+		var certs []*x509.Certificate
+		for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+			cert, err := x509.ParseCertificate(block.Bytes)
+			Expect(err).NotTo(HaveOccurred())
+			certs = append(certs, cert)
+		}
+		return certs
+	}
+
+	servingCertificate := func() *x509.Certificate {
+		certificate, err := rotator.GetCertificate(nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		cert, err := x509.ParseCertificate(certificate.Certificate[0])
+		Expect(err).NotTo(HaveOccurred())
+		return cert
+	}
+
+	verify := func(cert *x509.Certificate, caBundle []byte) error {
+		roots := x509.NewCertPool()
+		roots.AppendCertsFromPEM(caBundle)
+		_, err := cert.Verify(x509.VerifyOptions{DNSName: dnsName, Roots: roots, CurrentTime: now})
+		return err
+	}
+
+	It("has no certificate before the first sync", func() {
+		_, err := rotator.GetCertificate(&tls.ClientHelloInfo{})
+		Expect(err).To(HaveOccurred())
+	})
+
+	When("there is no secret", func() {
+		JustBeforeEach(func() {
+			Expect(rotator.Sync(ctx)).To(Succeed())
+		})
+
+		It("stores a CA and a serving certificate signed by it", func() {
+			secret := secret()
+			Expect(secret.Type).To(Equal(corev1.SecretTypeTLS))
+			Expect(secret.Data).To(HaveKey(CAKey))
+			Expect(certificates(secret.Data[CACertKey])).To(HaveLen(1))
+			Expect(verify(certificates(secret.Data[corev1.TLSCertKey])[0], secret.Data[CACertKey])).To(Succeed())
+		})
+
+		It("serves the serving certificate", func() {
+			Expect(servingCertificate().Raw).To(Equal(certificates(secret().Data[corev1.TLSCertKey])[0].Raw))
+			Expect(servingCertificate().NotAfter).To(Equal(now.Add(certValidity)))
+		})
+
+		It("sets the caBundle of the webhook configurations and API services", func() {
+			caBundle := secret().Data[CACertKey]
+
+			mutating := &admissionregistrationv1.MutatingWebhookConfiguration{}
+			Expect(fakeClient.Get(ctx, client.ObjectKey{Name: "mutating"}, mutating)).To(Succeed())
+			Expect(mutating.Webhooks[0].ClientConfig.CABundle).To(Equal(caBundle))
+			Expect(mutating.Webhooks[1].ClientConfig.CABundle).To(Equal(caBundle))
+
+			validating := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+			Expect(fakeClient.Get(ctx, client.ObjectKey{Name: "validating"}, validating)).To(Succeed())
+			Expect(validating.Webhooks[0].ClientConfig.CABundle).To(Equal(caBundle))
+
+			apiService := &unstructured.Unstructured{}
+			apiService.SetAPIVersion("apiregistration.k8s.io/v1")
+			apiService.SetKind("APIService")
+			Expect(fakeClient.Get(ctx, client.ObjectKey{Name: "v1alpha1.user.projects.vmware.com"}, apiService)).To(Succeed())
+			encoded, _, _ := unstructured.NestedString(apiService.Object, "spec", "caBundle")
+			Expect(encoded).To(Equal(base64.StdEncoding.EncodeToString(caBundle)))
+		})
+	})
+
+	When("the certificates are valid", func() {
+		It("leaves them alone", func() {
+			Expect(rotator.Sync(ctx)).To(Succeed())
+			before := secret()
+
+			now = now.Add(certValidity / 2)
+			Expect(rotator.Sync(ctx)).To(Succeed())
+
+			Expect(secret().ResourceVersion).To(Equal(before.ResourceVersion))
+		})
+	})
+
+	When("the serving certificate is about to expire", func() {
+		It("renews it with the same CA", func() {
+			Expect(rotator.Sync(ctx)).To(Succeed())
+			before := secret()
+
+			now = now.Add(certValidity * 3 / 4)
+			Expect(rotator.Sync(ctx)).To(Succeed())
+
+			after := secret()
+			Expect(after.Data[CACertKey]).To(Equal(before.Data[CACertKey]))
+			Expect(after.Data[corev1.TLSCertKey]).NotTo(Equal(before.Data[corev1.TLSCertKey]))
+			Expect(servingCertificate().NotAfter).To(Equal(now.Add(certValidity)))
+			Expect(verify(servingCertificate(), after.Data[CACertKey])).To(Succeed())
+		})
+	})
+
+	When("the CA is about to expire", func() {
+		var oldCA *x509.Certificate
+
+		BeforeEach(func() {
+			Expect(rotator.Sync(ctx)).To(Succeed())
+			oldCA = certificates(secret().Data[CACertKey])[0]
+
+			// the serving certificate is renewed by the old CA just before the
+			// CA itself is renewed
+			now = now.Add(caValidity*2/3 - 48*time.Hour)
+			Expect(rotator.Sync(ctx)).To(Succeed())
+			Expect(servingCertificate().CheckSignatureFrom(oldCA)).To(Succeed())
+
+			now = now.Add(72 * time.Hour)
+			Expect(rotator.Sync(ctx)).To(Succeed())
+		})
+
+		It("adds a new CA to the bundle and keeps trusting the old one", func() {
+			bundle := certificates(secret().Data[CACertKey])
+			Expect(bundle).To(HaveLen(2))
+			Expect(bundle[0].Raw).NotTo(Equal(oldCA.Raw))
+			Expect(bundle[1].Raw).To(Equal(oldCA.Raw))
+		})
+
+		It("keeps serving a certificate of the old CA until the next sync", func() {
+			Expect(servingCertificate().CheckSignatureFrom(oldCA)).To(Succeed())
+			Expect(verify(servingCertificate(), secret().Data[CACertKey])).To(Succeed())
+
+			Expect(rotator.Sync(ctx)).To(Succeed())
+
+			newCA := certificates(secret().Data[CACertKey])[0]
+			Expect(servingCertificate().CheckSignatureFrom(newCA)).To(Succeed())
+		})
+
+		It("drops the old CA once it has expired", func() {
+			now = oldCA.NotAfter.Add(time.Minute)
+			Expect(rotator.Sync(ctx)).To(Succeed())
+
+			Expect(certificates(secret().Data[CACertKey])).To(HaveLen(1))
+			Expect(verify(servingCertificate(), secret().Data[CACertKey])).To(Succeed())
+		})
+	})
+
+	When("the serving certificate is not valid for the DNS names", func() {
+		It("replaces it straight away", func() {
+			Expect(rotator.Sync(ctx)).To(Succeed())
+
+			stored := secret()
+			stored.Data[corev1.TLSCertKey] = stored.Data[CACertKey]
+			Expect(fakeClient.Update(ctx, stored)).To(Succeed())
+
+			Expect(rotator.Sync(ctx)).To(Succeed())
+			Expect(verify(servingCertificate(), secret().Data[CACertKey])).To(Succeed())
+		})
+	})
+})
@@ -30,6 +30,16 @@ func AddFinalizer(obj metav1.Object, finalizer string) {
 	obj.SetFinalizers(append(finalizers, finalizer))
 }
 
+func HasFinalizer(obj metav1.Object, finalizer string) bool {
+	for _, f := range obj.GetFinalizers() {
+		if f == finalizer {
+			return true
+		}
+	}
+
+	return false
+}
+
 func RemoveFinalizer(obj metav1.Object, finalizer string) {
 	finalizers := obj.GetFinalizers()
 
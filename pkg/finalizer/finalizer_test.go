@@ -40,6 +40,18 @@ var _ = Describe("Finalizer", func() {
 		Entry("finalizer exists", []string{"finalizer.1", "finalizer.2"}, "finalizer.1", []string{"finalizer.1", "finalizer.2"}),
 	)
 
+	DescribeTable("HasFinalizer",
+		func(existingFinalizers []string, finalizer string, expected bool) {
+			obj := &metav1.ObjectMeta{}
+			obj.SetFinalizers(existingFinalizers)
+
+			Expect(HasFinalizer(obj, finalizer)).To(Equal(expected))
+		},
+		Entry("no existing finalizers", []string{}, "finalizer.1", false),
+		Entry("finalizer exists", []string{"finalizer.2", "finalizer.1"}, "finalizer.1", true),
+		Entry("no finalizer found", []string{"finalizer.3", "finalizer.2"}, "finalizer.1", false),
+	)
+
 	DescribeTable("RemoveFinalizer",
 		func(existingFinalizers []string, deleteFinalizer string, expectedFinalizers []string) {
 			obj := &metav1.ObjectMeta{}
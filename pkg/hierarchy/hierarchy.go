@@ -0,0 +1,138 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package hierarchy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+)
+
+// CycleError is returned when a project is its own ancestor
+type CycleError struct {
+	// Path lists the projects in the cycle, starting and ending with the
+	// same project
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("project hierarchy contains a cycle: %s", strings.Join(e.Path, " -> "))
+}
+
+// ByName indexes projects by their name
+func ByName(projectList []projects.Project) map[string]projects.Project {
+	byName := make(map[string]projects.Project, len(projectList))
+	for _, project := range projectList {
+		byName[project.Name] = project
+	}
+	return byName
+}
+
+// Ancestors returns the ancestors of the project, nearest first. A parent
+// that does not exist ends the hierarchy. If the hierarchy contains a cycle
+// the ancestors up to the cycle are returned along with a CycleError.
+func Ancestors(project projects.Project, byName map[string]projects.Project) ([]projects.Project, error) {
+	var ancestors []projects.Project
+
+	path := []string{project.Name}
+	seen := map[string]bool{project.Name: true}
+
+	for parentName := project.Spec.Parent; parentName != ""; {
+		path = append(path, parentName)
+		if seen[parentName] {
+			return ancestors, &CycleError{Path: path[indexOf(path, parentName):]}
+		}
+		seen[parentName] = true
+
+		parent, ok := byName[parentName]
+		if !ok {
+			break
+		}
+		ancestors = append(ancestors, parent)
+		parentName = parent.Spec.Parent
+	}
+
+	return ancestors, nil
+}
+
+// Grants returns the subjects in the access of the project followed by those
+// in the access of its ancestors, whatever their validity
+func Grants(project projects.Project, byName map[string]projects.Project) ([]projects.SubjectRef, error) {
+	ancestors, err := Ancestors(project, byName)
+
+	grants := append([]projects.SubjectRef{}, project.Spec.Access...)
+	for _, ancestor := range ancestors {
+		grants = append(grants, ancestor.Spec.Access...)
+	}
+
+	return grants, err
+}
+
+// Active returns the grants that are active at the given time. A subject that
+// is granted the same role more than once is only returned the first time.
+func Active(grants []projects.SubjectRef, now time.Time) []projects.SubjectRef {
+	type subjectRole struct {
+		kind, name, namespace, role string
+	}
+
+	var active []projects.SubjectRef
+	seen := map[subjectRole]bool{}
+	for _, grant := range grants {
+		key := subjectRole{string(grant.Kind), grant.Name, grant.Namespace, grant.Role}
+		if seen[key] || !grant.ActiveAt(now) {
+			continue
+		}
+		seen[key] = true
+		active = append(active, grant)
+	}
+
+	return active
+}
+
+// Access returns the subjects with access to the project at the given time,
+// including those it inherits from its ancestors
+func Access(project projects.Project, byName map[string]projects.Project, now time.Time) ([]projects.SubjectRef, error) {
+	grants, err := Grants(project, byName)
+	return Active(grants, now), err
+}
+
+// Descendants returns the names of all projects below the named project,
+// sorted by name
+func Descendants(name string, projectList []projects.Project) []string {
+	children := map[string][]string{}
+	for _, project := range projectList {
+		if project.Spec.Parent != "" {
+			children[project.Spec.Parent] = append(children[project.Spec.Parent], project.Name)
+		}
+	}
+
+	var descendants []string
+	seen := map[string]bool{name: true}
+	queue := []string{name}
+	for len(queue) > 0 {
+		for _, child := range children[queue[0]] {
+			if !seen[child] {
+				seen[child] = true
+				descendants = append(descendants, child)
+				queue = append(queue, child)
+			}
+		}
+		queue = queue[1:]
+	}
+	sort.Strings(descendants)
+
+	return descendants
+}
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,125 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package hierarchy_test
+
+import (
+	"time"
+
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/pivotal/projects-operator/pkg/hierarchy"
+)
+
+func project(name, parent string, groups ...string) projects.Project {
+	project := projects.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       projects.ProjectSpec{Parent: parent},
+	}
+	for _, group := range groups {
+		project.Spec.Access = append(project.Spec.Access, projects.SubjectRef{Kind: "Group", Name: group})
+	}
+	return project
+}
+
+var _ = Describe("Hierarchy", func() {
+	var projectList []projects.Project
+
+	BeforeEach(func() {
+		projectList = []projects.Project{
+			project("department", "", "department-admins"),
+			project("team", "department", "team-members", "department-admins"),
+			project("app", "team", "app-developers"),
+			project("other", ""),
+		}
+	})
+
+	Describe("Ancestors", func() {
+		It("returns the ancestors nearest first", func() {
+			ancestors, err := Ancestors(projectList[2], ByName(projectList))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ancestors).To(HaveLen(2))
+			Expect(ancestors[0].Name).To(Equal("team"))
+			Expect(ancestors[1].Name).To(Equal("department"))
+		})
+
+		It("stops at a parent that does not exist", func() {
+			ancestors, err := Ancestors(project("orphan", "missing"), ByName(projectList))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ancestors).To(BeEmpty())
+		})
+
+		It("returns an error for a cycle", func() {
+			byName := ByName(projectList)
+			byName["department"] = project("department", "app")
+
+			_, err := Ancestors(byName["app"], byName)
+			Expect(err).To(MatchError("project hierarchy contains a cycle: app -> team -> department -> app"))
+		})
+
+		It("returns an error for a project that is its own parent", func() {
+			_, err := Ancestors(project("self", "self"), ByName(projectList))
+			Expect(err).To(BeAssignableToTypeOf(&CycleError{}))
+		})
+	})
+
+	Describe("Access", func() {
+		It("includes the access of every ancestor once", func() {
+			access, err := Access(projectList[2], ByName(projectList), time.Now())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(access).To(Equal([]projects.SubjectRef{
+				{Kind: "Group", Name: "app-developers"},
+				{Kind: "Group", Name: "team-members"},
+				{Kind: "Group", Name: "department-admins"},
+			}))
+		})
+	})
+
+	Describe("Active", func() {
+		var (
+			now    time.Time
+			past   *metav1.Time
+			future *metav1.Time
+		)
+
+		BeforeEach(func() {
+			now = time.Now()
+			past = &metav1.Time{Time: now.Add(-time.Hour)}
+			future = &metav1.Time{Time: now.Add(time.Hour)}
+		})
+
+		It("ignores grants outside their window", func() {
+			grants := []projects.SubjectRef{
+				{Kind: "User", Name: "expired", ExpiresAt: past},
+				{Kind: "User", Name: "pending", NotBefore: future},
+				{Kind: "User", Name: "current", NotBefore: past, ExpiresAt: future},
+			}
+
+			Expect(Active(grants, now)).To(Equal(grants[2:]))
+		})
+
+		It("keeps a later grant of a subject whose earlier grant expired", func() {
+			grants := []projects.SubjectRef{
+				{Kind: "User", Name: "alice", ExpiresAt: past},
+				{Kind: "User", Name: "alice"},
+			}
+
+			Expect(Active(grants, now)).To(Equal(grants[1:]))
+		})
+	})
+
+	Describe("Descendants", func() {
+		It("returns all projects below the project", func() {
+			Expect(Descendants("department", projectList)).To(Equal([]string{"app", "team"}))
+			Expect(Descendants("app", projectList)).To(BeEmpty())
+		})
+
+		It("terminates on a cycle", func() {
+			projectList[0].Spec.Parent = "app"
+			Expect(Descendants("department", projectList)).To(Equal([]string{"app", "team"}))
+		})
+	})
+})
@@ -0,0 +1,77 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package keypolicy
+
+import "strings"
+
+// DefaultDeniedKeys are the label and annotation keys reserved for Kubernetes
+// and the operator itself
+var DefaultDeniedKeys = []string{
+	"kubernetes.io/*",
+	"k8s.io/*",
+	"pod-security.kubernetes.io/*",
+	"projects.vmware.com/*",
+}
+
+// Policy decides which label and annotation keys may be propagated from a
+// Project to its namespace. Each pattern is either an exact key or a prefix
+// ending in '*'.
+//
+// A key matching Allowed is always permitted. Otherwise, if Allowed is
+// non-empty the key is rejected, and if Allowed is empty the key is permitted
+// unless it matches Denied.
+type Policy struct {
+	Allowed []string
+	Denied  []string
+}
+
+func DefaultPolicy() Policy {
+	return Policy{Denied: DefaultDeniedKeys}
+}
+
+func (p Policy) Permits(key string) bool {
+	if matchesAny(p.Allowed, key) {
+		return true
+	}
+
+	if len(p.Allowed) > 0 {
+		return false
+	}
+
+	return !matchesAny(p.Denied, key)
+}
+
+// Filter returns the entries of m permitted by the policy, along with the keys
+// that were dropped
+func (p Policy) Filter(m map[string]string) (map[string]string, []string) {
+	if m == nil {
+		return nil, nil
+	}
+
+	permitted := make(map[string]string, len(m))
+	var rejected []string
+	for key, value := range m {
+		if p.Permits(key) {
+			permitted[key] = value
+		} else {
+			rejected = append(rejected, key)
+		}
+	}
+
+	return permitted, rejected
+}
+
+func matchesAny(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if prefix := strings.TrimSuffix(pattern, "*"); prefix != pattern {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if pattern == key {
+			return true
+		}
+	}
+
+	return false
+}
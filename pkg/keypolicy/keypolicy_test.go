@@ -0,0 +1,46 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package keypolicy_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/pivotal/projects-operator/pkg/keypolicy"
+)
+
+var _ = Describe("Policy", func() {
+	DescribeTable("Permits",
+		func(policy Policy, key string, expected bool) {
+			Expect(policy.Permits(key)).To(Equal(expected))
+		},
+		Entry("default policy, ordinary key", DefaultPolicy(), "team.example.com/owner", true),
+		Entry("default policy, pod security key", DefaultPolicy(), "pod-security.kubernetes.io/enforce", false),
+		Entry("default policy, operator key", DefaultPolicy(), "projects.vmware.com/project", false),
+		Entry("empty policy", Policy{}, "pod-security.kubernetes.io/enforce", true),
+		Entry("exact denied key", Policy{Denied: []string{"cost-centre"}}, "cost-centre", false),
+		Entry("exact key is not a prefix", Policy{Denied: []string{"cost-centre"}}, "cost-centre-id", true),
+		Entry("allowed overrides denied", Policy{Allowed: []string{"pod-security.kubernetes.io/warn"}, Denied: DefaultDeniedKeys}, "pod-security.kubernetes.io/warn", true),
+		Entry("allowlist rejects other keys", Policy{Allowed: []string{"team.example.com/*"}}, "other.example.com/owner", false),
+		Entry("allowlist permits matching keys", Policy{Allowed: []string{"team.example.com/*"}}, "team.example.com/owner", true),
+	)
+
+	Describe("Filter", func() {
+		It("splits the permitted entries from the rejected keys", func() {
+			permitted, rejected := DefaultPolicy().Filter(map[string]string{
+				"team.example.com/owner":             "alice",
+				"pod-security.kubernetes.io/enforce": "privileged",
+			})
+
+			Expect(permitted).To(Equal(map[string]string{"team.example.com/owner": "alice"}))
+			Expect(rejected).To(ConsistOf("pod-security.kubernetes.io/enforce"))
+		})
+
+		It("returns nil for nil input", func() {
+			permitted, rejected := DefaultPolicy().Filter(nil)
+
+			Expect(permitted).To(BeNil())
+			Expect(rejected).To(BeEmpty())
+		})
+	})
+})
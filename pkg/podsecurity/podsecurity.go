@@ -0,0 +1,98 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package podsecurity
+
+import (
+	"fmt"
+
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+)
+
+// The namespace labels read by Pod Security Admission
+const (
+	EnforceLabel = "pod-security.kubernetes.io/enforce"
+	AuditLabel   = "pod-security.kubernetes.io/audit"
+	WarnLabel    = "pod-security.kubernetes.io/warn"
+)
+
+// ModeLabels are the labels of every Pod Security Admission mode
+var ModeLabels = []string{EnforceLabel, AuditLabel, WarnLabel}
+
+// Config holds the operator-wide levels
+type Config struct {
+	// Default is enforced on projects that do not set a level
+	Default projects.PodSecurityLevel
+	// Maximum is the most permissive level a project may use
+	Maximum projects.PodSecurityLevel
+}
+
+// strictness orders the levels from the most permissive. Pod Security
+// Admission treats a missing or unknown level as privileged.
+func strictness(level projects.PodSecurityLevel) int {
+	switch level {
+	case projects.PodSecurityRestricted:
+		return 2
+	case projects.PodSecurityBaseline:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Weaker reports whether level is more permissive than other
+func Weaker(level, other projects.PodSecurityLevel) bool {
+	return strictness(level) < strictness(other)
+}
+
+// Exceeds reports whether level is more permissive than the maximum
+func (c Config) Exceeds(level projects.PodSecurityLevel) bool {
+	return level != "" && Weaker(level, c.Maximum)
+}
+
+// NamespaceLabels returns the Pod Security Admission labels for a project
+// namespace. Levels more permissive than the maximum are raised to it. No
+// labels are returned when neither the project nor the operator sets a level.
+func (c Config) NamespaceLabels(podSecurity *projects.PodSecurity) map[string]string {
+	if podSecurity == nil && c == (Config{}) {
+		return nil
+	}
+
+	var requested projects.PodSecurity
+	if podSecurity != nil {
+		requested = *podSecurity
+	}
+
+	enforce := c.level(requested.Enforce, c.Default)
+
+	return map[string]string{
+		EnforceLabel: string(enforce),
+		AuditLabel:   string(c.level(requested.Audit, enforce)),
+		WarnLabel:    string(c.level(requested.Warn, enforce)),
+	}
+}
+
+func (c Config) level(requested, fallback projects.PodSecurityLevel) projects.PodSecurityLevel {
+	level := requested
+	if level == "" {
+		level = fallback
+	}
+	if level == "" {
+		level = projects.PodSecurityPrivileged
+	}
+
+	if Weaker(level, c.Maximum) {
+		return c.Maximum
+	}
+	return level
+}
+
+// ParseLevel parses a level name, returning "" for an empty string
+func ParseLevel(name string) (projects.PodSecurityLevel, error) {
+	switch level := projects.PodSecurityLevel(name); level {
+	case "", projects.PodSecurityPrivileged, projects.PodSecurityBaseline, projects.PodSecurityRestricted:
+		return level, nil
+	default:
+		return "", fmt.Errorf("unknown pod security level '%s'", name)
+	}
+}
@@ -0,0 +1,16 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package podsecurity_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestPodSecurity(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "PodSecurity Suite")
+}
@@ -0,0 +1,74 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package podsecurity_test
+
+import (
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/pivotal/projects-operator/pkg/podsecurity"
+)
+
+var _ = Describe("PodSecurity", func() {
+	DescribeTable("Weaker",
+		func(level, other projects.PodSecurityLevel, expected bool) {
+			Expect(Weaker(level, other)).To(Equal(expected))
+		},
+		Entry("privileged is weaker than baseline", projects.PodSecurityPrivileged, projects.PodSecurityBaseline, true),
+		Entry("baseline is weaker than restricted", projects.PodSecurityBaseline, projects.PodSecurityRestricted, true),
+		Entry("a level is not weaker than itself", projects.PodSecurityBaseline, projects.PodSecurityBaseline, false),
+		Entry("restricted is not weaker than privileged", projects.PodSecurityRestricted, projects.PodSecurityPrivileged, false),
+		Entry("a missing level is privileged", projects.PodSecurityLevel(""), projects.PodSecurityBaseline, true),
+	)
+
+	Describe("NamespaceLabels", func() {
+		var config Config
+
+		BeforeEach(func() {
+			config = Config{Default: projects.PodSecurityBaseline, Maximum: projects.PodSecurityBaseline}
+		})
+
+		It("returns no labels when no level is set", func() {
+			Expect(Config{}.NamespaceLabels(nil)).To(BeNil())
+		})
+
+		It("uses the default level when the project sets none", func() {
+			Expect(config.NamespaceLabels(nil)).To(Equal(map[string]string{
+				EnforceLabel: "baseline",
+				AuditLabel:   "baseline",
+				WarnLabel:    "baseline",
+			}))
+		})
+
+		It("defaults audit and warn to the enforced level", func() {
+			Expect(config.NamespaceLabels(&projects.PodSecurity{Enforce: projects.PodSecurityRestricted})).To(Equal(map[string]string{
+				EnforceLabel: "restricted",
+				AuditLabel:   "restricted",
+				WarnLabel:    "restricted",
+			}))
+		})
+
+		It("raises levels more permissive than the maximum", func() {
+			Expect(config.NamespaceLabels(&projects.PodSecurity{
+				Enforce: projects.PodSecurityPrivileged,
+				Warn:    projects.PodSecurityRestricted,
+			})).To(Equal(map[string]string{
+				EnforceLabel: "baseline",
+				AuditLabel:   "baseline",
+				WarnLabel:    "restricted",
+			}))
+		})
+	})
+
+	DescribeTable("Exceeds",
+		func(maximum, level projects.PodSecurityLevel, expected bool) {
+			Expect(Config{Maximum: maximum}.Exceeds(level)).To(Equal(expected))
+		},
+		Entry("no maximum", projects.PodSecurityLevel(""), projects.PodSecurityPrivileged, false),
+		Entry("more permissive than the maximum", projects.PodSecurityBaseline, projects.PodSecurityPrivileged, true),
+		Entry("at the maximum", projects.PodSecurityBaseline, projects.PodSecurityBaseline, false),
+		Entry("unset level", projects.PodSecurityBaseline, projects.PodSecurityLevel(""), false),
+	)
+})
@@ -0,0 +1,179 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// Options configure the TLS and timeouts of a Server
+type Options struct {
+	// MinVersion is the minimum TLS version, tls.VersionTLS12 when 0
+	MinVersion uint16
+
+	// CipherSuites are the TLS 1.2 cipher suites, the Go defaults when empty
+	CipherSuites []uint16
+
+	// ReadTimeout and WriteTimeout bound the time spent reading a request and
+	// writing its response
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+
+	// ShutdownDelay is how long the server keeps serving, while its readiness
+	// check fails, once it is stopped. It gives load balancers time to stop
+	// sending it new requests.
+	ShutdownDelay time.Duration
+
+	// ShutdownTimeout is how long in-flight requests are given to complete
+	// once the server stops accepting connections
+	ShutdownTimeout time.Duration
+}
+
+// Server serves HTTPS until its context is done, then drains its connections
+type Server struct {
+	logger          logr.Logger
+	server          *http.Server
+	shutdownDelay   time.Duration
+	shutdownTimeout time.Duration
+	stopping        int32
+}
+
+// New returns a Server of the handler on the address. Its certificate comes
+// from getCertificate, so that a renewed certificate is served without a
+// restart, and the TLS config is cloned from base when it is not nil.
+func New(logger logr.Logger, addr string, handler http.Handler, base *tls.Config, getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error), options Options) *Server {
+	tlsConfig := &tls.Config{}
+	if base != nil {
+		tlsConfig = base.Clone()
+	}
+	tlsConfig.GetCertificate = getCertificate
+	tlsConfig.MinVersion = options.MinVersion
+	if tlsConfig.MinVersion == 0 {
+		tlsConfig.MinVersion = tls.VersionTLS12
+	}
+	tlsConfig.CipherSuites = options.CipherSuites
+
+	return &Server{
+		logger: logger,
+		server: &http.Server{
+			Addr:              addr,
+			Handler:           handler,
+			TLSConfig:         tlsConfig,
+			ReadTimeout:       options.ReadTimeout,
+			ReadHeaderTimeout: options.ReadTimeout,
+			WriteTimeout:      options.WriteTimeout,
+		},
+		shutdownDelay:   options.ShutdownDelay,
+		shutdownTimeout: options.ShutdownTimeout,
+	}
+}
+
+// ReadyCheck fails once the server is stopping, so that the pod is taken out
+// of its service before the server stops accepting connections
+func (s *Server) ReadyCheck(_ *http.Request) error {
+	if atomic.LoadInt32(&s.stopping) != 0 {
+		return errors.New("server is shutting down")
+	}
+	return nil
+}
+
+// Start listens on the address of the server and serves until the context
+// is done
+func (s *Server) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(ctx, listener)
+}
+
+// Serve serves on the listener until the context is done. It then fails its
+// readiness check and keeps serving for the shutdown delay, before it stops
+// accepting connections and waits up to the shutdown timeout for in-flight
+// requests to complete. Connections that are still open after that, such as
+// watches, are closed.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	errs := make(chan error, 1)
+	go func() {
+		s.logger.Info("starting server", "addr", listener.Addr().String())
+		errs <- s.server.ServeTLS(listener, "", "")
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	atomic.StoreInt32(&s.stopping, 1)
+	if s.shutdownDelay > 0 {
+		s.logger.Info("failing readiness before shutting down server", "delay", s.shutdownDelay)
+
+		select {
+		case err := <-errs:
+			return err
+		case <-time.After(s.shutdownDelay):
+		}
+	}
+
+	s.logger.Info("shutting down server", "timeout", s.shutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	if err := s.server.Shutdown(shutdownCtx); err != nil {
+		s.logger.Info("closing connections that did not complete", "reason", err.Error())
+		_ = s.server.Close()
+	}
+
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// ParseTLSVersion parses a TLS version such as "1.2", returning 0 for an
+// empty string
+func ParseTLSVersion(version string) (uint16, error) {
+	switch version {
+	case "":
+		return 0, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS version '%s', must be 1.2 or 1.3", version)
+	}
+}
+
+// ParseCipherSuites parses the names of secure cipher suites, such as
+// TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	ids := map[string]uint16{}
+	for _, suite := range tls.CipherSuites() {
+		ids[suite.Name] = suite.ID
+	}
+
+	var suites []uint16
+	for _, name := range names {
+		id, ok := ids[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure cipher suite '%s'", name)
+		}
+		suites = append(suites, id)
+	}
+	return suites, nil
+}
@@ -0,0 +1,242 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package server_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/pivotal/projects-operator/pkg/server"
+)
+
+var _ = Describe("Server", func() {
+	var (
+		certificate *tls.Certificate
+		pool        *x509.CertPool
+		options     Options
+		release     chan struct{}
+		releaseOnce sync.Once
+		started     chan struct{}
+		listener    net.Listener
+		srv         *Server
+		ctx         context.Context
+		cancel      context.CancelFunc
+		done        chan struct{}
+		serveErr    error
+	)
+
+	BeforeEach(func() {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "localhost"},
+			DNSNames:     []string{"localhost"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			IsCA:         true,
+
+			BasicConstraintsValid: true,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+		Expect(err).NotTo(HaveOccurred())
+
+		cert, err := x509.ParseCertificate(der)
+		Expect(err).NotTo(HaveOccurred())
+		pool = x509.NewCertPool()
+		pool.AddCert(cert)
+
+		certificate = &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+		options = Options{ShutdownTimeout: 5 * time.Second}
+		release = make(chan struct{})
+		releaseOnce = sync.Once{}
+		started = make(chan struct{}, 10)
+	})
+
+	JustBeforeEach(func() {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			started <- struct{}{}
+			<-release
+			fmt.Fprint(w, "done")
+		})
+		getCertificate := func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return certificate, nil
+		}
+
+		var err error
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx, cancel = context.WithCancel(context.Background())
+		done = make(chan struct{})
+		srv = New(logr.Discard(), listener.Addr().String(), handler, nil, getCertificate, options)
+		go func() {
+			serveErr = srv.Serve(ctx, listener)
+			close(done)
+		}()
+	})
+
+	releaseRequests := func() {
+		releaseOnce.Do(func() { close(release) })
+	}
+
+	AfterEach(func() {
+		cancel()
+		releaseRequests()
+		Eventually(done).Should(BeClosed())
+	})
+
+	client := func(maxVersion uint16) *http.Client {
+		return &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool, ServerName: "localhost", MaxVersion: maxVersion},
+		}}
+	}
+
+	get := func(client *http.Client) (string, error) {
+		response, err := client.Get(fmt.Sprintf("https://%s/", listener.Addr().String()))
+		if err != nil {
+			return "", err
+		}
+		defer response.Body.Close()
+
+		body, err := ioutil.ReadAll(response.Body)
+		return string(body), err
+	}
+
+	It("serves the certificate from getCertificate", func() {
+		releaseRequests()
+
+		body, err := get(client(0))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(body).To(Equal("done"))
+	})
+
+	It("completes in-flight requests when the context is done", func() {
+		responses := make(chan string, 1)
+		go func() {
+			defer GinkgoRecover()
+			body, err := get(client(0))
+			Expect(err).NotTo(HaveOccurred())
+			responses <- body
+		}()
+		Eventually(started).Should(Receive())
+
+		cancel()
+		Consistently(done).ShouldNot(BeClosed())
+
+		releaseRequests()
+		Eventually(responses).Should(Receive(Equal("done")))
+		Eventually(done).Should(BeClosed())
+		Expect(serveErr).NotTo(HaveOccurred())
+
+		_, err := get(client(0))
+		Expect(err).To(HaveOccurred())
+	})
+
+	When("in-flight requests outlive the shutdown timeout", func() {
+		BeforeEach(func() {
+			options.ShutdownTimeout = 100 * time.Millisecond
+		})
+
+		It("closes their connections", func() {
+			errs := make(chan error, 1)
+			go func() {
+				_, err := get(client(0))
+				errs <- err
+			}()
+			Eventually(started).Should(Receive())
+
+			cancel()
+			Eventually(done).Should(BeClosed())
+			Expect(serveErr).NotTo(HaveOccurred())
+			Eventually(errs).Should(Receive(HaveOccurred()))
+		})
+	})
+
+	When("a shutdown delay is set", func() {
+		BeforeEach(func() {
+			options.ShutdownDelay = time.Second
+		})
+
+		It("fails its readiness check and keeps serving until the delay has passed", func() {
+			releaseRequests()
+			Expect(srv.ReadyCheck(nil)).To(Succeed())
+
+			cancel()
+			Eventually(func() error { return srv.ReadyCheck(nil) }).Should(MatchError("server is shutting down"))
+
+			body, err := get(client(0))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(body).To(Equal("done"))
+			Expect(done).NotTo(BeClosed())
+
+			Eventually(done, 2*time.Second).Should(BeClosed())
+			Expect(serveErr).NotTo(HaveOccurred())
+		})
+	})
+
+	When("the minimum TLS version is 1.3", func() {
+		BeforeEach(func() {
+			options.MinVersion = tls.VersionTLS13
+		})
+
+		It("refuses TLS 1.2 clients", func() {
+			_, err := get(client(tls.VersionTLS12))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("ParseTLSVersion", func() {
+	DescribeTable("versions",
+		func(version string, expected uint16) {
+			Expect(ParseTLSVersion(version)).To(Equal(expected))
+		},
+		Entry("unset", "", uint16(0)),
+		Entry("1.2", "1.2", uint16(tls.VersionTLS12)),
+		Entry("1.3", "1.3", uint16(tls.VersionTLS13)),
+	)
+
+	It("rejects older and unknown versions", func() {
+		_, err := ParseTLSVersion("1.0")
+		Expect(err).To(MatchError("unsupported TLS version '1.0', must be 1.2 or 1.3"))
+	})
+})
+
+var _ = Describe("ParseCipherSuites", func() {
+	It("parses the names of secure cipher suites", func() {
+		Expect(ParseCipherSuites([]string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"})).To(Equal([]uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		}))
+	})
+
+	It("returns no suites for no names", func() {
+		Expect(ParseCipherSuites(nil)).To(BeEmpty())
+	})
+
+	It("rejects insecure and unknown cipher suites", func() {
+		_, err := ParseCipherSuites([]string{"TLS_RSA_WITH_RC4_128_SHA"})
+		Expect(err).To(MatchError("unknown or insecure cipher suite 'TLS_RSA_WITH_RC4_128_SHA'"))
+	})
+})
@@ -0,0 +1,125 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package userprojects
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// the configmap in which the API server publishes how aggregated APIs
+// authenticate the requests it proxies to them
+const (
+	authenticationConfigMapNamespace = "kube-system"
+	authenticationConfigMapName      = "extension-apiserver-authentication"
+)
+
+// RequestHeaderConfig describes the front proxy client certificate and the
+// headers with which the API server passes on the requesting user
+type RequestHeaderConfig struct {
+	ClientCAs           *x509.CertPool
+	AllowedNames        []string
+	UsernameHeaders     []string
+	GroupHeaders        []string
+	ExtraHeaderPrefixes []string
+}
+
+// LoadRequestHeaderConfig reads the request header config published by the
+// API server
+func LoadRequestHeaderConfig(ctx context.Context, reader client.Reader) (RequestHeaderConfig, error) {
+	configMap := &corev1.ConfigMap{}
+	key := client.ObjectKey{Namespace: authenticationConfigMapNamespace, Name: authenticationConfigMapName}
+	if err := reader.Get(ctx, key, configMap); err != nil {
+		return RequestHeaderConfig{}, err
+	}
+
+	clientCA := configMap.Data["requestheader-client-ca-file"]
+	if clientCA == "" {
+		return RequestHeaderConfig{}, errors.New("no requestheader-client-ca-file in " + key.String())
+	}
+
+	config := RequestHeaderConfig{ClientCAs: x509.NewCertPool()}
+	if !config.ClientCAs.AppendCertsFromPEM([]byte(clientCA)) {
+		return RequestHeaderConfig{}, errors.New("invalid requestheader-client-ca-file in " + key.String())
+	}
+
+	for name, list := range map[string]*[]string{
+		"requestheader-allowed-names":        &config.AllowedNames,
+		"requestheader-username-headers":     &config.UsernameHeaders,
+		"requestheader-group-headers":        &config.GroupHeaders,
+		"requestheader-extra-headers-prefix": &config.ExtraHeaderPrefixes,
+	} {
+		if value := configMap.Data[name]; value != "" {
+			if err := json.Unmarshal([]byte(value), list); err != nil {
+				return RequestHeaderConfig{}, fmt.Errorf("invalid %s in %s: %w", name, key.String(), err)
+			}
+		}
+	}
+
+	return config, nil
+}
+
+// Authenticate returns the user that the API server proxied the request for.
+// Only requests with a front proxy client certificate are trusted.
+func (c RequestHeaderConfig) Authenticate(r *http.Request) (authenticationv1.UserInfo, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.PeerCertificates) == 0 {
+		return authenticationv1.UserInfo{}, false
+	}
+
+	if len(c.AllowedNames) > 0 && !contains(c.AllowedNames, r.TLS.PeerCertificates[0].Subject.CommonName) {
+		return authenticationv1.UserInfo{}, false
+	}
+
+	user := authenticationv1.UserInfo{}
+	for _, header := range c.UsernameHeaders {
+		if user.Username = r.Header.Get(header); user.Username != "" {
+			break
+		}
+	}
+	if user.Username == "" {
+		return authenticationv1.UserInfo{}, false
+	}
+
+	for _, header := range c.GroupHeaders {
+		user.Groups = append(user.Groups, r.Header.Values(header)...)
+	}
+
+	for header, values := range r.Header {
+		for _, prefix := range c.ExtraHeaderPrefixes {
+			if !strings.HasPrefix(strings.ToLower(header), strings.ToLower(prefix)) {
+				continue
+			}
+
+			key, err := url.PathUnescape(strings.ToLower(header[len(prefix):]))
+			if err != nil {
+				continue
+			}
+			if user.Extra == nil {
+				user.Extra = map[string]authenticationv1.ExtraValue{}
+			}
+			user.Extra[key] = append(user.Extra[key], values...)
+		}
+	}
+
+	return user, true
+}
+
+func contains(list []string, item string) bool {
+	for _, listItem := range list {
+		if listItem == item {
+			return true
+		}
+	}
+	return false
+}
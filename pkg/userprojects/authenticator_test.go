@@ -0,0 +1,127 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package userprojects_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/pivotal/projects-operator/pkg/userprojects"
+)
+
+var _ = Describe("RequestHeaderConfig", func() {
+	var (
+		config  RequestHeaderConfig
+		request *http.Request
+	)
+
+	BeforeEach(func() {
+		config = RequestHeaderConfig{
+			AllowedNames:        []string{"front-proxy-client"},
+			UsernameHeaders:     []string{"X-Remote-User"},
+			GroupHeaders:        []string{"X-Remote-Group"},
+			ExtraHeaderPrefixes: []string{"X-Remote-Extra-"},
+		}
+
+		clientCert := &x509.Certificate{Subject: pkix.Name{CommonName: "front-proxy-client"}}
+		request = httptest.NewRequest(http.MethodGet, "/apis", nil)
+		request.TLS = &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{clientCert},
+			VerifiedChains:   [][]*x509.Certificate{{clientCert}},
+		}
+		request.Header.Set("X-Remote-User", "alice")
+		request.Header.Add("X-Remote-Group", "group-a")
+		request.Header.Add("X-Remote-Group", "system:authenticated")
+		request.Header.Add("X-Remote-Extra-Scopes%2fTeam", "payments")
+	})
+
+	It("returns the user from the request headers", func() {
+		user, ok := config.Authenticate(request)
+		Expect(ok).To(BeTrue())
+		Expect(user).To(Equal(authenticationv1.UserInfo{
+			Username: "alice",
+			Groups:   []string{"group-a", "system:authenticated"},
+			Extra:    map[string]authenticationv1.ExtraValue{"scopes/team": {"payments"}},
+		}))
+	})
+
+	It("rejects requests without a verified client certificate", func() {
+		request.TLS.VerifiedChains = nil
+
+		_, ok := config.Authenticate(request)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("rejects client certificates with a name that is not allowed", func() {
+		request.TLS.PeerCertificates[0].Subject.CommonName = "someone-else"
+
+		_, ok := config.Authenticate(request)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("rejects requests without a user", func() {
+		request.Header.Del("X-Remote-User")
+
+		_, ok := config.Authenticate(request)
+		Expect(ok).To(BeFalse())
+	})
+
+	Describe("LoadRequestHeaderConfig", func() {
+		It("reads the config published by the API server", func() {
+			key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+			template := &x509.Certificate{
+				SerialNumber:          big.NewInt(1),
+				Subject:               pkix.Name{CommonName: "front-proxy-ca"},
+				NotBefore:             time.Now(),
+				NotAfter:              time.Now().Add(time.Hour),
+				IsCA:                  true,
+				BasicConstraintsValid: true,
+			}
+			der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+			Expect(err).NotTo(HaveOccurred())
+
+			configMap := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "extension-apiserver-authentication"},
+				Data: map[string]string{
+					"requestheader-client-ca-file":       string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+					"requestheader-allowed-names":        `["front-proxy-client"]`,
+					"requestheader-username-headers":     `["X-Remote-User"]`,
+					"requestheader-group-headers":        `["X-Remote-Group"]`,
+					"requestheader-extra-headers-prefix": `["X-Remote-Extra-"]`,
+				},
+			}
+
+			loaded, err := LoadRequestHeaderConfig(context.Background(), fake.NewClientBuilder().WithObjects(configMap).Build())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loaded.ClientCAs).NotTo(BeNil())
+			Expect(loaded.AllowedNames).To(Equal([]string{"front-proxy-client"}))
+			Expect(loaded.UsernameHeaders).To(Equal([]string{"X-Remote-User"}))
+			Expect(loaded.GroupHeaders).To(Equal([]string{"X-Remote-Group"}))
+			Expect(loaded.ExtraHeaderPrefixes).To(Equal([]string{"X-Remote-Extra-"}))
+		})
+
+		It("fails without the config", func() {
+			_, err := LoadRequestHeaderConfig(context.Background(), fake.NewClientBuilder().Build())
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,96 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package userprojects
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func apiGroup() metav1.APIGroup {
+	version := metav1.GroupVersionForDiscovery{
+		GroupVersion: GroupVersion.String(),
+		Version:      GroupVersion.Version,
+	}
+
+	return metav1.APIGroup{
+		TypeMeta:         metav1.TypeMeta{Kind: "APIGroup", APIVersion: "v1"},
+		Name:             GroupVersion.Group,
+		Versions:         []metav1.GroupVersionForDiscovery{version},
+		PreferredVersion: version,
+	}
+}
+
+func apiResourceList() metav1.APIResourceList {
+	return metav1.APIResourceList{
+		TypeMeta:     metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+		GroupVersion: GroupVersion.String(),
+		APIResources: []metav1.APIResource{{
+			Name:         Resource,
+			SingularName: strings.ToLower(Kind),
+			Namespaced:   false,
+			Kind:         Kind,
+			Verbs:        metav1.Verbs{"get", "list", "watch"},
+		}},
+	}
+}
+
+// wantsTable reports whether kubectl asked for the server side printed table
+func wantsTable(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "as=Table")
+}
+
+func table(items []UserProject, resourceVersion string) metav1.Table {
+	rows := make([]metav1.TableRow, 0, len(items))
+	for _, item := range items {
+		metadata := metav1.PartialObjectMetadata{
+			TypeMeta:   metav1.TypeMeta{Kind: "PartialObjectMetadata", APIVersion: "meta.k8s.io/v1"},
+			ObjectMeta: item.ObjectMeta,
+		}
+		rows = append(rows, metav1.TableRow{
+			Cells:  []interface{}{item.Name, item.Status.Namespace, item.Status.Role, item.Status.Admin},
+			Object: runtime.RawExtension{Raw: mustMarshal(metadata)},
+		})
+	}
+
+	return metav1.Table{
+		TypeMeta: metav1.TypeMeta{Kind: "Table", APIVersion: "meta.k8s.io/v1"},
+		ListMeta: metav1.ListMeta{ResourceVersion: resourceVersion},
+		ColumnDefinitions: []metav1.TableColumnDefinition{
+			{Name: "Name", Type: "string", Format: "name"},
+			{Name: "Namespace", Type: "string"},
+			{Name: "Role", Type: "string"},
+			{Name: "Admin", Type: "boolean"},
+		},
+		Rows: rows,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, object interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(mustMarshal(object))
+}
+
+func writeStatus(w http.ResponseWriter, err *apierrors.StatusError) {
+	status := err.ErrStatus
+	status.TypeMeta = metav1.TypeMeta{Kind: "Status", APIVersion: "v1"}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(int(status.Code))
+	_, _ = w.Write(mustMarshal(status))
+}
+
+// mustMarshal marshals the API types of this package, which cannot fail
+func mustMarshal(object interface{}) []byte {
+	data, err := json.Marshal(object)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
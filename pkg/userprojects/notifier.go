@@ -0,0 +1,68 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package userprojects
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Notifier tells watches that projects have changed. Its generation counts
+// the changes and, together with the time the notifier was created, is used
+// as the resource version of the aggregated API.
+type Notifier struct {
+	mutex      sync.Mutex
+	epoch      string
+	generation uint64
+	watchers   map[chan struct{}]struct{}
+}
+
+func NewNotifier() *Notifier {
+	return &Notifier{
+		epoch:    strconv.FormatInt(time.Now().UnixNano(), 10),
+		watchers: map[chan struct{}]struct{}{},
+	}
+}
+
+// Notify records a change to the projects
+func (n *Notifier) Notify() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.generation++
+	for watcher := range n.watchers {
+		select {
+		case watcher <- struct{}{}:
+		default:
+			// the watcher has not yet caught up with an earlier change
+		}
+	}
+}
+
+// ResourceVersion returns the current resource version. Versions issued by
+// other processes, or before a restart, never match it.
+func (n *Notifier) ResourceVersion() string {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	return n.epoch + "-" + strconv.FormatUint(n.generation, 10)
+}
+
+// Subscribe returns a channel that receives a value after changes to the
+// projects, and a function that ends the subscription
+func (n *Notifier) Subscribe() (<-chan struct{}, func()) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	watcher := make(chan struct{}, 1)
+	n.watchers[watcher] = struct{}{}
+
+	return watcher, func() {
+		n.mutex.Lock()
+		defer n.mutex.Unlock()
+
+		delete(n.watchers, watcher)
+	}
+}
@@ -0,0 +1,320 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package userprojects
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/pivotal/projects-operator/pkg/webhook"
+)
+
+// +kubebuilder:rbac:groups=user.projects.vmware.com,resources=userprojects,verbs=get;list;watch
+
+//go:generate counterfeiter . Authenticator
+
+// Authenticator returns the user a request was made for
+type Authenticator interface {
+	Authenticate(*http.Request) (authenticationv1.UserInfo, bool)
+}
+
+// Server serves the userprojects aggregated API, which lists the projects
+// the requesting user has access to
+type Server struct {
+	authenticator   Authenticator
+	accessMode      webhook.AccessMode
+	projectFetcher  webhook.ProjectFetcher
+	projectFilterer webhook.ProjectFilterer
+	notifier        *Notifier
+	logger          logr.Logger
+}
+
+func NewServer(logger logr.Logger, authenticator Authenticator, accessMode webhook.AccessMode, projectFetcher webhook.ProjectFetcher, projectFilterer webhook.ProjectFilterer, notifier *Notifier) *Server {
+	return &Server{
+		authenticator:   authenticator,
+		accessMode:      accessMode,
+		projectFetcher:  projectFetcher,
+		projectFilterer: projectFilterer,
+		notifier:        notifier,
+		logger:          logger,
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeStatus(w, apierrors.NewMethodNotSupported(GroupVersion.WithResource(Resource).GroupResource(), r.Method))
+		return
+	}
+
+	prefix := []string{"apis", GroupVersion.Group, GroupVersion.Version}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	for i, part := range parts {
+		if i >= len(prefix) {
+			break
+		}
+		if part != prefix[i] {
+			writeStatus(w, apierrors.NewNotFound(GroupVersion.WithResource(Resource).GroupResource(), r.URL.Path))
+			return
+		}
+	}
+
+	var rest []string
+	if len(parts) > len(prefix) {
+		rest = parts[len(prefix):]
+	}
+
+	switch {
+	case len(parts) == 1:
+		writeJSON(w, metav1.APIGroupList{
+			TypeMeta: metav1.TypeMeta{Kind: "APIGroupList", APIVersion: "v1"},
+			Groups:   []metav1.APIGroup{apiGroup()},
+		})
+	case len(parts) == 2:
+		writeJSON(w, apiGroup())
+	case len(parts) == 3:
+		writeJSON(w, apiResourceList())
+	case len(rest) == 1 && rest[0] == Resource:
+		if watch, _ := strconv.ParseBool(r.URL.Query().Get("watch")); watch {
+			s.authenticated(s.watch)(w, r)
+		} else {
+			s.authenticated(s.list)(w, r)
+		}
+	case len(rest) == 2 && rest[0] == "watch" && rest[1] == Resource:
+		s.authenticated(s.watch)(w, r)
+	case len(rest) == 2 && rest[0] == Resource:
+		s.authenticated(func(w http.ResponseWriter, r *http.Request, user authenticationv1.UserInfo) {
+			s.get(w, r, user, rest[1])
+		})(w, r)
+	default:
+		writeStatus(w, apierrors.NewNotFound(GroupVersion.WithResource(Resource).GroupResource(), r.URL.Path))
+	}
+}
+
+func (s *Server) authenticated(handle func(http.ResponseWriter, *http.Request, authenticationv1.UserInfo)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := s.authenticator.Authenticate(r)
+		if !ok {
+			writeStatus(w, apierrors.NewUnauthorized("the request was not proxied by the API server"))
+			return
+		}
+
+		handle(w, r, user)
+	}
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request, user authenticationv1.UserInfo) {
+	selector, err := labels.Parse(r.URL.Query().Get("labelSelector"))
+	if err != nil {
+		writeStatus(w, apierrors.NewBadRequest(err.Error()))
+		return
+	}
+
+	items, resourceVersion, err := s.userProjects(user, selector)
+	if err != nil {
+		s.logger.Error(err, "error fetching Projects", "user", user.Username)
+		writeStatus(w, apierrors.NewInternalError(err))
+		return
+	}
+
+	if wantsTable(r) {
+		writeJSON(w, table(items, resourceVersion))
+		return
+	}
+
+	writeJSON(w, UserProjectList{
+		TypeMeta: metav1.TypeMeta{Kind: Kind + "List", APIVersion: GroupVersion.String()},
+		ListMeta: metav1.ListMeta{ResourceVersion: resourceVersion},
+		Items:    items,
+	})
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request, user authenticationv1.UserInfo, name string) {
+	items, resourceVersion, err := s.userProjects(user, labels.Everything())
+	if err != nil {
+		s.logger.Error(err, "error fetching Projects", "user", user.Username)
+		writeStatus(w, apierrors.NewInternalError(err))
+		return
+	}
+
+	for _, item := range items {
+		if item.Name == name {
+			if wantsTable(r) {
+				writeJSON(w, table([]UserProject{item}, resourceVersion))
+			} else {
+				writeJSON(w, item)
+			}
+			return
+		}
+	}
+
+	writeStatus(w, apierrors.NewNotFound(GroupVersion.WithResource(Resource).GroupResource(), name))
+}
+
+// watch streams the changes to the projects of the user. A watch from the
+// current resource version starts from the current projects and a watch
+// without one, or from "0", starts by adding them all. No history is kept,
+// so any other resource version has expired and the client must list again.
+func (s *Server) watch(w http.ResponseWriter, r *http.Request, user authenticationv1.UserInfo) {
+	query := r.URL.Query()
+	selector, err := labels.Parse(query.Get("labelSelector"))
+	if err != nil {
+		writeStatus(w, apierrors.NewBadRequest(err.Error()))
+		return
+	}
+
+	ctx := r.Context()
+	if timeout, err := strconv.Atoi(query.Get("timeoutSeconds")); err == nil && timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		defer cancel()
+	}
+
+	updates, unsubscribe := s.notifier.Subscribe()
+	defer unsubscribe()
+
+	current := map[string]UserProject{}
+	if resourceVersion := query.Get("resourceVersion"); resourceVersion != "" && resourceVersion != "0" {
+		items, itemsVersion, err := s.userProjects(user, selector)
+		if err != nil {
+			s.logger.Error(err, "error fetching Projects", "user", user.Username)
+			writeStatus(w, apierrors.NewInternalError(err))
+			return
+		}
+		if itemsVersion != resourceVersion {
+			writeStatus(w, apierrors.NewResourceExpired("too old resource version: "+resourceVersion+" ("+itemsVersion+")"))
+			return
+		}
+		current = byName(items)
+	}
+
+	asTable := wantsTable(r)
+	flusher, _ := w.(http.Flusher)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	for {
+		items, resourceVersion, err := s.userProjects(user, selector)
+		if err != nil {
+			s.logger.Error(err, "error fetching Projects", "user", user.Username)
+			status := apierrors.NewInternalError(err).ErrStatus
+			_ = encoder.Encode(metav1.WatchEvent{Type: string(watch.Error), Object: runtime.RawExtension{Raw: mustMarshal(status)}})
+			return
+		}
+
+		next := byName(items)
+		for _, event := range changes(current, next) {
+			var object interface{} = event.object
+			if asTable {
+				object = table([]UserProject{event.object}, resourceVersion)
+			}
+
+			if err := encoder.Encode(metav1.WatchEvent{Type: string(event.eventType), Object: runtime.RawExtension{Raw: mustMarshal(object)}}); err != nil {
+				return
+			}
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		current = next
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-updates:
+		}
+	}
+}
+
+// userProjects returns the projects the user has access to that match the
+// selector, and the resource version they were read at
+func (s *Server) userProjects(user authenticationv1.UserInfo, selector labels.Selector) ([]UserProject, string, error) {
+	resourceVersion := s.notifier.ResourceVersion()
+
+	accessibleProjects, projectList, err := webhook.AccessibleProjects(s.accessMode, s.projectFetcher, s.projectFilterer, user)
+	if err != nil {
+		return nil, "", err
+	}
+
+	projectLabels := make(map[string]map[string]string, len(projectList))
+	for _, project := range projectList {
+		projectLabels[project.Name] = project.Labels
+	}
+
+	var items []UserProject
+	for _, accessible := range accessibleProjects {
+		if !selector.Matches(labels.Set(projectLabels[accessible.Name])) {
+			continue
+		}
+
+		items = append(items, UserProject{
+			TypeMeta: metav1.TypeMeta{Kind: Kind, APIVersion: GroupVersion.String()},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            accessible.Name,
+				Labels:          projectLabels[accessible.Name],
+				ResourceVersion: resourceVersion,
+			},
+			Status: UserProjectStatus{
+				Namespace: accessible.Namespace,
+				Role:      accessible.Role,
+				Admin:     accessible.Admin,
+				Subjects:  accessible.Subjects,
+			},
+		})
+	}
+
+	return items, resourceVersion, nil
+}
+
+type event struct {
+	eventType watch.EventType
+	object    UserProject
+}
+
+// changes returns the events that turn the previous projects into the next,
+// ordered by name
+func changes(previous, next map[string]UserProject) []event {
+	var events []event
+	for name, item := range next {
+		old, ok := previous[name]
+		switch {
+		case !ok:
+			events = append(events, event{watch.Added, item})
+		case !reflect.DeepEqual(old.Labels, item.Labels) || !reflect.DeepEqual(old.Status, item.Status):
+			events = append(events, event{watch.Modified, item})
+		}
+	}
+	for name, item := range previous {
+		if _, ok := next[name]; !ok {
+			events = append(events, event{watch.Deleted, item})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].object.Name < events[j].object.Name
+	})
+
+	return events
+}
+
+func byName(items []UserProject) map[string]UserProject {
+	result := make(map[string]UserProject, len(items))
+	for _, item := range items {
+		result[item.Name] = item
+	}
+	return result
+}
@@ -0,0 +1,239 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package userprojects_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/go-logr/logr"
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	"github.com/pivotal/projects-operator/pkg/webhook"
+	"github.com/pivotal/projects-operator/pkg/webhook/webhookfakes"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/pivotal/projects-operator/pkg/userprojects"
+	"github.com/pivotal/projects-operator/pkg/userprojects/userprojectsfakes"
+)
+
+var _ = Describe("Server", func() {
+	var (
+		server           *httptest.Server
+		notifier         *Notifier
+		user             authenticationv1.UserInfo
+		fakeAuth         *userprojectsfakes.FakeAuthenticator
+		fakeFetcher      *webhookfakes.FakeProjectFetcher
+		fakeFilterer     *webhookfakes.FakeProjectFilterer
+		accessibleResult []projects.AccessibleProject
+	)
+
+	const path = "/apis/user.projects.vmware.com/v1alpha1/userprojects"
+
+	get := func(path string, header ...string) *http.Response {
+		request, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		Expect(err).NotTo(HaveOccurred())
+		if len(header) == 2 {
+			request.Header.Set(header[0], header[1])
+		}
+
+		response, err := http.DefaultClient.Do(request)
+		Expect(err).NotTo(HaveOccurred())
+		return response
+	}
+
+	decode := func(response *http.Response, object interface{}) {
+		defer response.Body.Close()
+		Expect(json.NewDecoder(response.Body).Decode(object)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		user = authenticationv1.UserInfo{Username: "alice", Groups: []string{"group-a"}}
+		fakeAuth = new(userprojectsfakes.FakeAuthenticator)
+		fakeAuth.AuthenticateReturns(user, true)
+
+		fakeFetcher = new(webhookfakes.FakeProjectFetcher)
+		fakeFetcher.GetProjectsForUserReturns([]projects.Project{
+			{ObjectMeta: metav1.ObjectMeta{Name: "project-a", Labels: map[string]string{"tier": "web"}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "project-b"}},
+		}, nil)
+
+		accessibleResult = []projects.AccessibleProject{
+			{Name: "project-a", Namespace: "project-a", Role: "view"},
+			{Name: "project-b", Namespace: "project-b", Admin: true},
+		}
+		fakeFilterer = new(webhookfakes.FakeProjectFilterer)
+		fakeFilterer.FilterProjectsStub = func([]projects.Project, authenticationv1.UserInfo) ([]projects.AccessibleProject, error) {
+			return accessibleResult, nil
+		}
+
+		notifier = NewNotifier()
+		server = httptest.NewServer(NewServer(logr.Discard(), fakeAuth, webhook.AccessModeLiteral, fakeFetcher, fakeFilterer, notifier))
+	})
+
+	AfterEach(func() {
+		server.CloseClientConnections()
+		server.Close()
+	})
+
+	It("serves discovery", func() {
+		response := get("/apis/user.projects.vmware.com/v1alpha1")
+		Expect(response.StatusCode).To(Equal(http.StatusOK))
+
+		resources := metav1.APIResourceList{}
+		decode(response, &resources)
+		Expect(resources.GroupVersion).To(Equal("user.projects.vmware.com/v1alpha1"))
+		Expect(resources.APIResources).To(HaveLen(1))
+		Expect(resources.APIResources[0].Name).To(Equal("userprojects"))
+		Expect(resources.APIResources[0].Verbs).To(ConsistOf("get", "list", "watch"))
+	})
+
+	It("lists the projects of the user", func() {
+		response := get(path)
+		Expect(response.StatusCode).To(Equal(http.StatusOK))
+
+		list := UserProjectList{}
+		decode(response, &list)
+		Expect(list.Kind).To(Equal("UserProjectList"))
+		Expect(list.Items).To(HaveLen(2))
+		Expect(list.Items[0].Name).To(Equal("project-a"))
+		Expect(list.Items[0].Status).To(Equal(UserProjectStatus{Namespace: "project-a", Role: "view"}))
+		Expect(list.Items[1].Status.Admin).To(BeTrue())
+
+		Expect(fakeFetcher.GetProjectsForUserArgsForCall(0)).To(Equal(user))
+		_, filteredUser := fakeFilterer.FilterProjectsArgsForCall(0)
+		Expect(filteredUser).To(Equal(user))
+	})
+
+	It("filters the projects by label", func() {
+		list := UserProjectList{}
+		decode(get(path+"?labelSelector=tier%3Dweb"), &list)
+
+		Expect(list.Items).To(HaveLen(1))
+		Expect(list.Items[0].Name).To(Equal("project-a"))
+	})
+
+	It("prints the projects as a table for kubectl", func() {
+		table := metav1.Table{}
+		decode(get(path, "Accept", "application/json;as=Table;v=v1;g=meta.k8s.io,application/json"), &table)
+
+		Expect(table.Kind).To(Equal("Table"))
+		Expect(table.Rows).To(HaveLen(2))
+		Expect(table.Rows[0].Cells).To(Equal([]interface{}{"project-a", "project-a", "view", false}))
+	})
+
+	It("gets a project of the user", func() {
+		project := UserProject{}
+		decode(get(path+"/project-b"), &project)
+
+		Expect(project.Name).To(Equal("project-b"))
+	})
+
+	It("does not find projects the user has no access to", func() {
+		response := get(path + "/project-c")
+		Expect(response.StatusCode).To(Equal(http.StatusNotFound))
+
+		status := metav1.Status{}
+		decode(response, &status)
+		Expect(status.Reason).To(Equal(metav1.StatusReasonNotFound))
+	})
+
+	It("rejects requests that were not proxied by the API server", func() {
+		fakeAuth.AuthenticateReturns(authenticationv1.UserInfo{}, false)
+
+		Expect(get(path).StatusCode).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("rejects writes", func() {
+		response, err := http.Post(server.URL+path, "application/json", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(response.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+	})
+
+	Describe("watch", func() {
+		nextEvent := func(reader *bufio.Reader) (string, UserProject) {
+			line, err := reader.ReadBytes('\n')
+			Expect(err).NotTo(HaveOccurred())
+
+			event := metav1.WatchEvent{}
+			Expect(json.Unmarshal(line, &event)).To(Succeed())
+
+			project := UserProject{}
+			Expect(json.Unmarshal(event.Object.Raw, &project)).To(Succeed())
+			return event.Type, project
+		}
+
+		It("adds the projects of the user and streams changes", func() {
+			response := get(path + "?watch=true")
+			defer response.Body.Close()
+			reader := bufio.NewReader(response.Body)
+
+			eventType, project := nextEvent(reader)
+			Expect(eventType).To(Equal("ADDED"))
+			Expect(project.Name).To(Equal("project-a"))
+			eventType, project = nextEvent(reader)
+			Expect(eventType).To(Equal("ADDED"))
+			Expect(project.Name).To(Equal("project-b"))
+
+			accessibleResult = []projects.AccessibleProject{{Name: "project-a", Namespace: "project-a", Role: "edit"}}
+			notifier.Notify()
+
+			eventType, project = nextEvent(reader)
+			Expect(eventType).To(Equal("MODIFIED"))
+			Expect(project.Status.Role).To(Equal("edit"))
+			eventType, project = nextEvent(reader)
+			Expect(eventType).To(Equal("DELETED"))
+			Expect(project.Name).To(Equal("project-b"))
+		})
+
+		It("resumes from the current resource version", func() {
+			list := UserProjectList{}
+			decode(get(path), &list)
+
+			response := get("/apis/user.projects.vmware.com/v1alpha1/watch/userprojects?resourceVersion=" + list.ResourceVersion)
+			defer response.Body.Close()
+			reader := bufio.NewReader(response.Body)
+
+			accessibleResult = append(accessibleResult, projects.AccessibleProject{Name: "project-c", Namespace: "project-c"})
+			notifier.Notify()
+
+			eventType, project := nextEvent(reader)
+			Expect(eventType).To(Equal("ADDED"))
+			Expect(project.Name).To(Equal("project-c"))
+			Expect(project.ResourceVersion).NotTo(Equal(list.ResourceVersion))
+		})
+
+		It("adds the projects of the user when watching from any resource version", func() {
+			response := get(path + "?watch=true&resourceVersion=0")
+			defer response.Body.Close()
+			reader := bufio.NewReader(response.Body)
+
+			eventType, project := nextEvent(reader)
+			Expect(eventType).To(Equal("ADDED"))
+			Expect(project.Name).To(Equal("project-a"))
+		})
+
+		DescribeTable("expires resource versions that are not current",
+			func(resourceVersion func() string) {
+				response := get(path + "?watch=true&resourceVersion=" + resourceVersion())
+				Expect(response.StatusCode).To(Equal(http.StatusGone))
+
+				status := metav1.Status{}
+				decode(response, &status)
+				Expect(status.Reason).To(Equal(metav1.StatusReasonExpired))
+			},
+			Entry("issued by another process", func() string { return "1" }),
+			Entry("issued by another notifier", func() string { return NewNotifier().ResourceVersion() }),
+			Entry("issued before a change", func() string {
+				resourceVersion := notifier.ResourceVersion()
+				notifier.Notify()
+				return resourceVersion
+			}),
+		)
+	})
+})
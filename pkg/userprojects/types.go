@@ -0,0 +1,47 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package userprojects
+
+import (
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// GroupName is the API group of the aggregated API
+	GroupName = "user.projects.vmware.com"
+	// Resource is the name of the only resource of the aggregated API
+	Resource = "userprojects"
+	// Kind is the kind of a UserProject
+	Kind = "UserProject"
+)
+
+// GroupVersion is the group version of the aggregated API
+var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+// UserProject is a project that the requesting user has access to
+type UserProject struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status UserProjectStatus `json:"status"`
+}
+
+// UserProjectStatus is the access the requesting user has to a project
+type UserProjectStatus struct {
+	Namespace string                `json:"namespace"`
+	Role      string                `json:"role,omitempty"`
+	Admin     bool                  `json:"admin"`
+	Subjects  []projects.SubjectRef `json:"subjects,omitempty"`
+}
+
+// UserProjectList is a list of the projects that the requesting user has
+// access to
+type UserProjectList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []UserProject `json:"items"`
+}
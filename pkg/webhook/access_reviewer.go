@@ -0,0 +1,73 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package webhook
+
+import (
+	"context"
+
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//go:generate counterfeiter . AccessReviewer
+
+type AccessReviewer interface {
+	CanImpersonate(authenticationv1.UserInfo) (bool, error)
+	CanAccessProject(user authenticationv1.UserInfo, name, verb string) (bool, error)
+}
+
+type accessReviewer struct {
+	client client.Client
+}
+
+func NewAccessReviewer(client client.Client) *accessReviewer {
+	return &accessReviewer{
+		client: client,
+	}
+}
+
+// CanImpersonate asks the API server whether the user may ask for the
+// projects of another user
+func (r *accessReviewer) CanImpersonate(user authenticationv1.UserInfo) (bool, error) {
+	return r.review(user, &authorizationv1.ResourceAttributes{
+		Group:    projects.GroupVersion.Group,
+		Resource: "projectaccesses",
+		Verb:     projects.ImpersonateVerb,
+	})
+}
+
+// CanAccessProject asks the API server whether the user may perform verb on
+// the named project, or on every project when the name is empty
+func (r *accessReviewer) CanAccessProject(user authenticationv1.UserInfo, name, verb string) (bool, error) {
+	return r.review(user, &authorizationv1.ResourceAttributes{
+		Group:    projects.GroupVersion.Group,
+		Resource: "projects",
+		Name:     name,
+		Verb:     verb,
+	})
+}
+
+func (r *accessReviewer) review(user authenticationv1.UserInfo, attributes *authorizationv1.ResourceAttributes) (bool, error) {
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for key, value := range user.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:               user.Username,
+			Groups:             user.Groups,
+			UID:                user.UID,
+			Extra:              extra,
+			ResourceAttributes: attributes,
+		},
+	}
+	if err := r.client.Create(context.TODO(), review); err != nil {
+		return false, err
+	}
+
+	return review.Status.Allowed, nil
+}
@@ -5,6 +5,7 @@ package webhook
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -12,19 +13,89 @@ import (
 
 	"github.com/go-logr/logr"
 	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/pivotal/projects-operator/pkg/podsecurity"
 )
 
 // +kubebuilder:rbac:groups=projects.vmware.com,resources=projectaccesses,verbs=get;create;delete
 
-func NewHandler(logger logr.Logger, namespaceFetcher NamespaceFetcher, projectFetcher ProjectFetcher, projectFilterer ProjectFilterer) http.Handler {
+// Config holds the settings of the webhook that are set at deploy time
+type Config struct {
+	// AdminGroups are the groups whose members may adopt existing
+	// namespaces into new projects
+	AdminGroups []string
+
+	// OperatorNamespace is the namespace the operator is deployed to, which
+	// projects may not be named after
+	OperatorNamespace string
+	// OperatorUsername is the username of the operator's service account,
+	// which refreshes ProjectAccesses on behalf of the user they recorded
+	OperatorUsername string
+	// IdentityPrefixes are stripped from the names of the User and Group
+	// subjects of projects
+	IdentityPrefixes []string
+	// MaxMembers caps the number of subjects in the access of a project, 0
+	// means no cap
+	MaxMembers int
+
+	// DefaultQuota is added to the quota of new projects for the resources
+	// they do not set
+	DefaultQuota corev1.ResourceList
+	// MaxQuota caps the quota of every project
+	MaxQuota corev1.ResourceList
+	// DefaultContainerLimits are the default container limits of new
+	// projects that do not set any limits
+	DefaultContainerLimits corev1.ResourceList
+	// MaxContainerLimits caps the container limits of every project
+	MaxContainerLimits corev1.ResourceList
+
+	// PodSecurity holds the default and maximum Pod Security Admission
+	// levels of project namespaces
+	PodSecurity podsecurity.Config
+
+	// ProjectAccessMode decides how the projects listed in a ProjectAccess
+	// are found
+	ProjectAccessMode AccessMode
+}
+
+// AccessMode decides how the projects a user has access to are found
+type AccessMode string
+
+const (
+	// AccessModeLiteral matches the user against the access of each project
+	AccessModeLiteral AccessMode = "literal"
+	// AccessModeRBAC asks the API server which projects the user may get
+	AccessModeRBAC AccessMode = "rbac"
+	// AccessModeUnion lists the projects found by either of the other modes
+	AccessModeUnion AccessMode = "union"
+)
+
+// ParseAccessMode parses the name of an access mode, which defaults to
+// literal
+func ParseAccessMode(name string) (AccessMode, error) {
+	switch mode := AccessMode(name); mode {
+	case "":
+		return AccessModeLiteral, nil
+	case AccessModeLiteral, AccessModeRBAC, AccessModeUnion:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown access mode '%s', must be one of literal, rbac or union", name)
+	}
+}
+
+func NewHandler(logger logr.Logger, config Config, namespaceFetcher NamespaceFetcher, projectFetcher ProjectFetcher, projectFilterer ProjectFilterer, accessReviewer AccessReviewer) http.Handler {
 	mux := http.NewServeMux()
 
-	projectHandler := NewProjectHandler(logger.WithName("project"), namespaceFetcher)
-	projectAccessHandler := NewProjectAccessHandler(logger.WithName("projectaccess"), projectFetcher, projectFilterer)
+	projectHandler := NewProjectHandler(logger.WithName("project"), config, namespaceFetcher, projectFetcher)
+	namespaceHandler := NewNamespaceHandler(logger.WithName("namespace"), config, projectFetcher)
+	projectAccessHandler := NewProjectAccessHandler(logger.WithName("projectaccess"), config, projectFetcher, projectFilterer, accessReviewer)
 
 	mux.HandleFunc("/project", projectHandler.HandleProjectValidation)
 	mux.HandleFunc("/projectaccess", projectAccessHandler.HandleProjectAccess)
-	mux.HandleFunc("/project-create", projectHandler.HandleProjectCreation)
+	mux.HandleFunc("/project-create", projectHandler.HandleProjectDefaulting)
+	mux.HandleFunc("/namespace", namespaceHandler.HandleNamespaceValidation)
 
 	return mux
 }
@@ -49,21 +120,70 @@ func unmarshalToAdmissionReview(body []byte) (*admissionv1.AdmissionReview, erro
 		return &admissionv1.AdmissionReview{}, err
 	}
 
+	if arRequest.Request == nil {
+		return &admissionv1.AdmissionReview{}, errors.New("AdmissionReview has no request")
+	}
+
 	return arRequest, nil
 }
 
-func sendReview(w http.ResponseWriter, arReview *admissionv1.AdmissionReview) {
-	response, err := json.Marshal(arReview)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, `{"error marshalling response": "%s"}`, err)
+// sendReview answers the AdmissionReview with the response, echoing the UID of
+// its request and the apiVersion and kind it was sent with
+func sendReview(w http.ResponseWriter, arRequest *admissionv1.AdmissionReview, response *admissionv1.AdmissionResponse) {
+	response.UID = arRequest.Request.UID
+
+	typeMeta := arRequest.TypeMeta
+	if typeMeta.APIVersion == "" {
+		typeMeta = admissionReviewTypeMeta
+	}
+
+	writeReview(w, http.StatusOK, &admissionv1.AdmissionReview{TypeMeta: typeMeta, Response: response})
+}
+
+// denied returns a response that denies the request with a status of the
+// given code and reason
+func denied(code int32, reason metav1.StatusReason, message string) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Message: message,
+			Reason:  reason,
+			Code:    code,
+		},
+	}
+}
+
+// sendError denies a request that could not be handled. A body that is not an
+// AdmissionReview has no UID to echo and is also answered with the code as
+// the HTTP status.
+func sendError(w http.ResponseWriter, arRequest *admissionv1.AdmissionReview, code int32, message string) {
+	reason := metav1.StatusReasonInternalError
+	if code == http.StatusBadRequest {
+		reason = metav1.StatusReasonBadRequest
+	}
+
+	if arRequest == nil {
+		writeReview(w, int(code), &admissionv1.AdmissionReview{TypeMeta: admissionReviewTypeMeta, Response: denied(code, reason, message)})
 		return
 	}
 
-	_, err = w.Write(response)
+	sendReview(w, arRequest, denied(code, reason, message))
+}
+
+var admissionReviewTypeMeta = metav1.TypeMeta{
+	APIVersion: admissionv1.SchemeGroupVersion.String(),
+	Kind:       "AdmissionReview",
+}
+
+func writeReview(w http.ResponseWriter, statusCode int, arReview *admissionv1.AdmissionReview) {
+	body, err := json.Marshal(arReview)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, `{"error writing response": "%s"}`, err)
+		http.Error(w, fmt.Sprintf("error marshalling response: %s", err), http.StatusInternalServerError)
 		return
 	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_, _ = w.Write(body)
 }
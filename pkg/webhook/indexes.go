@@ -0,0 +1,109 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// AccessSubjectField indexes projects by the subjects in their access
+	AccessSubjectField = "spec.access.subject"
+	// ParentField indexes projects by their parent
+	ParentField = "spec.parent"
+	// BindingSubjectField indexes ClusterRoleBindings by their subjects
+	BindingSubjectField = "subjects"
+)
+
+// IndexProjects adds the project indexes used by the project fetcher
+func IndexProjects(ctx context.Context, indexer client.FieldIndexer) error {
+	if err := indexer.IndexField(ctx, &projects.Project{}, AccessSubjectField, AccessSubjectKeys); err != nil {
+		return err
+	}
+
+	return indexer.IndexField(ctx, &projects.Project{}, ParentField, ParentKeys)
+}
+
+// IndexClusterRoleBindings adds the ClusterRoleBinding index used by the
+// rbac project filterer
+func IndexClusterRoleBindings(ctx context.Context, indexer client.FieldIndexer) error {
+	return indexer.IndexField(ctx, &rbacv1.ClusterRoleBinding{}, BindingSubjectField, BindingSubjectKeys)
+}
+
+// AccessSubjectKeys returns the index keys of the subjects in the access of
+// a project. Service accounts are keyed by the username they authenticate as.
+func AccessSubjectKeys(obj client.Object) []string {
+	project, ok := obj.(*projects.Project)
+	if !ok {
+		return nil
+	}
+
+	var keys []string
+	for _, access := range project.Spec.Access {
+		switch access.Kind {
+		case "Group":
+			keys = append(keys, "group:"+access.Name)
+		case "User":
+			keys = append(keys, "user:"+access.Name)
+		case "ServiceAccount":
+			namespace := access.Namespace
+			if namespace == "" {
+				namespace = corev1.NamespaceDefault
+			}
+			keys = append(keys, fmt.Sprintf("user:system:serviceaccount:%s:%s", namespace, access.Name))
+		}
+	}
+
+	return keys
+}
+
+// BindingSubjectKeys returns the index keys of the subjects of a
+// ClusterRoleBinding, in the form of AccessSubjectKeys
+func BindingSubjectKeys(obj client.Object) []string {
+	binding, ok := obj.(*rbacv1.ClusterRoleBinding)
+	if !ok {
+		return nil
+	}
+
+	var keys []string
+	for _, subject := range binding.Subjects {
+		switch subject.Kind {
+		case rbacv1.GroupKind:
+			keys = append(keys, "group:"+subject.Name)
+		case rbacv1.UserKind:
+			keys = append(keys, "user:"+subject.Name)
+		case rbacv1.ServiceAccountKind:
+			keys = append(keys, fmt.Sprintf("user:system:serviceaccount:%s:%s", subject.Namespace, subject.Name))
+		}
+	}
+
+	return keys
+}
+
+// ParentKeys returns the index key of the parent of a project
+func ParentKeys(obj client.Object) []string {
+	project, ok := obj.(*projects.Project)
+	if !ok || project.Spec.Parent == "" {
+		return nil
+	}
+
+	return []string{project.Spec.Parent}
+}
+
+// userAccessKeys returns the index keys that match the user
+func userAccessKeys(user authenticationv1.UserInfo) []string {
+	keys := []string{"user:" + user.Username}
+	for _, group := range user.Groups {
+		keys = append(keys, "group:"+group)
+	}
+
+	return keys
+}
@@ -7,13 +7,14 @@ import (
 	"context"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 //go:generate counterfeiter . NamespaceFetcher
 
 type NamespaceFetcher interface {
-	GetNamespaces() ([]corev1.Namespace, error)
+	NamespaceExists(name string) (bool, error)
 }
 
 type namespaceFetcher struct {
@@ -26,9 +27,11 @@ func NewNamespaceFetcher(client client.Client) *namespaceFetcher {
 	}
 }
 
-func (f *namespaceFetcher) GetNamespaces() ([]corev1.Namespace, error) {
-	namespaceList := &corev1.NamespaceList{}
-	err := f.client.List(context.TODO(), namespaceList)
+func (f *namespaceFetcher) NamespaceExists(name string) (bool, error) {
+	err := f.client.Get(context.TODO(), client.ObjectKey{Name: name}, &corev1.Namespace{})
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
 
-	return namespaceList.Items, err
+	return err == nil, err
 }
@@ -37,12 +37,17 @@ var _ = Describe("NamespaceFetcher", func() {
 		fetcher = NewNamespaceFetcher(fakeClient)
 	})
 
-	Describe("GetNamespaces", func() {
-		It("returns a list of namespaces", func() {
-			namespaces, err := fetcher.GetNamespaces()
+	Describe("NamespaceExists", func() {
+		It("returns true for an existing namespace", func() {
+			exists, err := fetcher.NamespaceExists("namespace-a")
 			Expect(err).NotTo(HaveOccurred())
-			Expect(namespaces).To(HaveLen(2))
-			Expect([]string{namespaces[0].ObjectMeta.Name, namespaces[1].ObjectMeta.Name}).To(ConsistOf("namespace-a", "namespace-b"))
+			Expect(exists).To(BeTrue())
+		})
+
+		It("returns false for a missing namespace", func() {
+			exists, err := fetcher.NamespaceExists("namespace-c")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exists).To(BeFalse())
 		})
 	})
 })
@@ -0,0 +1,162 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-logr/logr"
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// NamespaceHandler validates the creation of and updates to project namespaces
+type NamespaceHandler struct {
+	ProjectFetcher ProjectFetcher
+	config         Config
+	logger         logr.Logger
+}
+
+func NewNamespaceHandler(logger logr.Logger, config Config, projectFetcher ProjectFetcher) *NamespaceHandler {
+	return &NamespaceHandler{
+		ProjectFetcher: projectFetcher,
+		config:         config,
+		logger:         logger,
+	}
+}
+
+// HandleNamespaceValidation rejects namespace updates that lower the pod
+// security labels of a project namespace below the level of its project, or
+// that remove or change its project label, which would exclude the namespace
+// from this webhook. The project label may only be added to, or changed on,
+// the namespace of the project that owns it.
+func (h *NamespaceHandler) HandleNamespaceValidation(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("handling namespace request")
+
+	body, err := ensureBody(r.Body)
+	if err != nil {
+		sendError(w, nil, http.StatusBadRequest, fmt.Sprintf("error reading body: %s", err))
+
+		h.logger.Error(err, "error reading body")
+		return
+	}
+
+	arRequest, err := unmarshalToAdmissionReview(body)
+	if err != nil {
+		sendError(w, nil, http.StatusBadRequest, fmt.Sprintf("error unmarshalling request body: %s", err))
+
+		h.logger.Error(err, "error unmarshaling AdmissionReview")
+		return
+	}
+
+	if arRequest.Request.Operation != admissionv1.Create && arRequest.Request.Operation != admissionv1.Update {
+		sendReview(w, arRequest, &admissionv1.AdmissionResponse{Allowed: true})
+		return
+	}
+
+	namespace := corev1.Namespace{}
+	if err := json.Unmarshal(arRequest.Request.Object.Raw, &namespace); err != nil {
+		sendError(w, arRequest, http.StatusBadRequest, fmt.Sprintf("error unmarshalling namespace: %s", err))
+
+		h.logger.Error(err, "error unmarshaling Namespace from AdmissionReview")
+		return
+	}
+
+	oldNamespace := corev1.Namespace{}
+	if arRequest.Request.Operation == admissionv1.Update {
+		if err := json.Unmarshal(arRequest.Request.OldObject.Raw, &oldNamespace); err != nil {
+			sendError(w, arRequest, http.StatusBadRequest, fmt.Sprintf("error unmarshalling old namespace: %s", err))
+
+			h.logger.Error(err, "error unmarshaling old Namespace from AdmissionReview")
+			return
+		}
+	}
+
+	project, err := h.project(oldNamespace, namespace)
+	if err != nil {
+		sendError(w, arRequest, http.StatusInternalServerError, fmt.Sprintf("error fetching projects: %s", err))
+
+		h.logger.Error(err, "error fetching Projects")
+		return
+	}
+
+	var messages []string
+	if projectLabelSet(oldNamespace, namespace) && !ownsNamespace(project, namespace) {
+		messages = append(messages, fmt.Sprintf("label %s can only be set on the namespace of the project that owns it", projects.ProjectLabel))
+	}
+
+	// the operator removes the project labels itself when it releases the
+	// namespace of a deleted project
+	if project != nil && project.DeletionTimestamp.IsZero() {
+		if namespace.Labels[projects.ProjectLabel] != project.Name && len(messages) == 0 {
+			messages = append(messages, fmt.Sprintf("label %s of project namespace '%s' cannot be removed or changed", projects.ProjectLabel, project.Name))
+		}
+		messages = append(messages, h.config.loweredPodSecurityLabels(*project, namespace.Labels)...)
+	}
+
+	if len(messages) > 0 {
+		sendReview(w, arRequest, denied(http.StatusForbidden, metav1.StatusReasonForbidden, strings.Join(messages, ", ")))
+		return
+	}
+
+	sendReview(w, arRequest, &admissionv1.AdmissionResponse{Allowed: true})
+}
+
+// project returns the project that owns the namespace, or nil if there is none.
+// A project namespace is always named after its project, and is owned by it
+// when the old or the new namespace has the project owner reference or label,
+// so that removing or adding either of them is also caught.
+func (h *NamespaceHandler) project(oldNamespace, namespace corev1.Namespace) (*projects.Project, error) {
+	if !claimsProject(oldNamespace) && !claimsProject(namespace) {
+		return nil, nil
+	}
+
+	return h.ProjectFetcher.GetProject(namespace.Name)
+}
+
+func claimsProject(namespace corev1.Namespace) bool {
+	return namespace.Labels[projects.ProjectLabel] == namespace.Name || hasProjectOwnerReference(namespace, "")
+}
+
+// projectLabelSet returns whether the project label is added to the namespace
+// or given a new value
+func projectLabelSet(oldNamespace, namespace corev1.Namespace) bool {
+	value, ok := namespace.Labels[projects.ProjectLabel]
+	if !ok {
+		return false
+	}
+
+	oldValue, hadLabel := oldNamespace.Labels[projects.ProjectLabel]
+	return !hadLabel || oldValue != value
+}
+
+// ownsNamespace returns whether the project exists, the namespace is named
+// after it and the new namespace has its owner reference
+func ownsNamespace(project *projects.Project, namespace corev1.Namespace) bool {
+	return project != nil &&
+		project.DeletionTimestamp.IsZero() &&
+		project.Name == namespace.Name &&
+		namespace.Labels[projects.ProjectLabel] == project.Name &&
+		hasProjectOwnerReference(namespace, project.UID)
+}
+
+// hasProjectOwnerReference returns whether the namespace is owned by the
+// project of the same name, and by the project with the given uid if it is set
+func hasProjectOwnerReference(namespace corev1.Namespace, uid types.UID) bool {
+	for _, ownerReference := range namespace.OwnerReferences {
+		gv, err := schema.ParseGroupVersion(ownerReference.APIVersion)
+		if err == nil && gv.Group == projects.GroupVersion.Group && ownerReference.Kind == "Project" && ownerReference.Name == namespace.Name &&
+			(uid == "" || ownerReference.UID == uid) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,302 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package webhook_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/go-logr/logr"
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	"github.com/pivotal/projects-operator/pkg/podsecurity"
+	"github.com/pivotal/projects-operator/testhelpers"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/pivotal/projects-operator/pkg/webhook"
+	"github.com/pivotal/projects-operator/pkg/webhook/webhookfakes"
+)
+
+var _ = Describe("NamespaceHandler", func() {
+	var (
+		responseRecorder *httptest.ResponseRecorder
+		h                http.Handler
+
+		fakeProjectFetcher *webhookfakes.FakeProjectFetcher
+		oldLabels          map[string]string
+	)
+
+	BeforeEach(func() {
+		responseRecorder = httptest.NewRecorder()
+
+		fakeProjectFetcher = new(webhookfakes.FakeProjectFetcher)
+		fakeProjectFetcher.GetProjectReturns(&projects.Project{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-project"},
+			Spec: projects.ProjectSpec{
+				PodSecurity: &projects.PodSecurity{Enforce: projects.PodSecurityBaseline},
+			},
+		}, nil)
+
+		oldLabels = map[string]string{
+			projects.ProjectLabel:    "my-project",
+			podsecurity.EnforceLabel: "baseline",
+			podsecurity.AuditLabel:   "baseline",
+			podsecurity.WarnLabel:    "baseline",
+		}
+
+		config := Config{PodSecurity: podsecurity.Config{Default: projects.PodSecurityRestricted}}
+		h = NewHandler(logr.Discard(), config, nil, fakeProjectFetcher, nil, nil)
+	})
+
+	review := func() *admissionv1.AdmissionReview {
+		Expect(responseRecorder.Result().StatusCode).To(Equal(http.StatusOK))
+
+		response, err := ioutil.ReadAll(responseRecorder.Result().Body)
+		Expect(err).NotTo(HaveOccurred())
+
+		var admissionReview *admissionv1.AdmissionReview
+		Expect(json.Unmarshal(response, &admissionReview)).To(Succeed())
+
+		return admissionReview
+	}
+
+	withLabels := func(changes map[string]string) map[string]string {
+		labels := map[string]string{}
+		for key, value := range oldLabels {
+			labels[key] = value
+		}
+		for key, value := range changes {
+			if value == "" {
+				delete(labels, key)
+				continue
+			}
+			labels[key] = value
+		}
+		return labels
+	}
+
+	It("permits raising the level", func() {
+		labels := withLabels(map[string]string{podsecurity.EnforceLabel: "restricted"})
+		h.ServeHTTP(responseRecorder, testhelpers.ValidUpdateRequestForNamespaceWebhookAPI(http.MethodPost, "/namespace", "my-project", oldLabels, labels))
+
+		Expect(review().Response.Allowed).To(BeTrue())
+	})
+
+	It("denies lowering the level", func() {
+		labels := withLabels(map[string]string{podsecurity.WarnLabel: "privileged"})
+		h.ServeHTTP(responseRecorder, testhelpers.ValidUpdateRequestForNamespaceWebhookAPI(http.MethodPost, "/namespace", "my-project", oldLabels, labels))
+
+		admissionReview := review()
+		Expect(admissionReview.Response.Allowed).To(BeFalse())
+		Expect(admissionReview.Response.Result.Message).To(Equal("label pod-security.kubernetes.io/warn of project namespace 'my-project' cannot be lower than 'baseline'"))
+	})
+
+	It("denies removing the level", func() {
+		labels := withLabels(map[string]string{podsecurity.EnforceLabel: ""})
+		h.ServeHTTP(responseRecorder, testhelpers.ValidUpdateRequestForNamespaceWebhookAPI(http.MethodPost, "/namespace", "my-project", oldLabels, labels))
+
+		Expect(review().Response.Allowed).To(BeFalse())
+	})
+
+	It("denies lowering the level while removing the project label", func() {
+		labels := withLabels(map[string]string{projects.ProjectLabel: "", podsecurity.EnforceLabel: "privileged"})
+		h.ServeHTTP(responseRecorder, testhelpers.ValidUpdateRequestForNamespaceWebhookAPI(http.MethodPost, "/namespace", "my-project", oldLabels, labels))
+
+		Expect(review().Response.Allowed).To(BeFalse())
+	})
+
+	It("denies removing the project label", func() {
+		labels := withLabels(map[string]string{projects.ProjectLabel: ""})
+		h.ServeHTTP(responseRecorder, testhelpers.ValidUpdateRequestForNamespaceWebhookAPI(http.MethodPost, "/namespace", "my-project", oldLabels, labels))
+
+		admissionReview := review()
+		Expect(admissionReview.Response.Allowed).To(BeFalse())
+		Expect(admissionReview.Response.Result.Message).To(Equal("label projects.vmware.com/project of project namespace 'my-project' cannot be removed or changed"))
+	})
+
+	It("denies pointing the project label at another project", func() {
+		labels := withLabels(map[string]string{projects.ProjectLabel: "other-project"})
+		h.ServeHTTP(responseRecorder, testhelpers.ValidUpdateRequestForNamespaceWebhookAPI(http.MethodPost, "/namespace", "my-project", oldLabels, labels))
+
+		Expect(review().Response.Allowed).To(BeFalse())
+	})
+
+	When("the project label has been removed before the level is lowered", func() {
+		It("finds the project from the owner reference of the namespace", func() {
+			ownerReferences := []metav1.OwnerReference{{
+				APIVersion: projects.GroupVersion.String(),
+				Kind:       "Project",
+				Name:       "my-project",
+			}}
+
+			// removing the label is denied in the first place
+			labels := withLabels(map[string]string{projects.ProjectLabel: ""})
+			h.ServeHTTP(responseRecorder, testhelpers.UpdateRequestForNamespaceWebhookAPI(http.MethodPost, "/namespace",
+				corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "my-project", Labels: oldLabels, OwnerReferences: ownerReferences}},
+				corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "my-project", Labels: labels, OwnerReferences: ownerReferences}},
+			))
+			Expect(review().Response.Allowed).To(BeFalse())
+
+			// and a namespace that lost it anyway is still protected
+			responseRecorder = httptest.NewRecorder()
+			lowered := withLabels(map[string]string{projects.ProjectLabel: "", podsecurity.EnforceLabel: "privileged"})
+			h.ServeHTTP(responseRecorder, testhelpers.UpdateRequestForNamespaceWebhookAPI(http.MethodPost, "/namespace",
+				corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "my-project", Labels: labels, OwnerReferences: ownerReferences}},
+				corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "my-project", Labels: lowered, OwnerReferences: ownerReferences}},
+			))
+
+			admissionReview := review()
+			Expect(admissionReview.Response.Allowed).To(BeFalse())
+			Expect(admissionReview.Response.Result.Message).To(ContainSubstring("label pod-security.kubernetes.io/enforce of project namespace 'my-project' cannot be lower than 'baseline'"))
+			Expect(fakeProjectFetcher.GetProjectArgsForCall(1)).To(Equal("my-project"))
+		})
+	})
+
+	Describe("setting the project label", func() {
+		var ownerReferences []metav1.OwnerReference
+
+		BeforeEach(func() {
+			fakeProjectFetcher.GetProjectReturns(&projects.Project{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-project", UID: "my-project-uid"},
+				Spec: projects.ProjectSpec{
+					PodSecurity: &projects.PodSecurity{Enforce: projects.PodSecurityBaseline},
+				},
+			}, nil)
+
+			ownerReferences = []metav1.OwnerReference{{
+				APIVersion: projects.GroupVersion.String(),
+				Kind:       "Project",
+				Name:       "my-project",
+				UID:        "my-project-uid",
+			}}
+		})
+
+		namespaceWith := func(labels map[string]string, ownerReferences []metav1.OwnerReference) corev1.Namespace {
+			return corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "my-project", Labels: labels, OwnerReferences: ownerReferences}}
+		}
+
+		It("permits the project that owns the namespace to add it", func() {
+			h.ServeHTTP(responseRecorder, testhelpers.UpdateRequestForNamespaceWebhookAPI(http.MethodPost, "/namespace",
+				namespaceWith(withLabels(map[string]string{projects.ProjectLabel: ""}), nil),
+				namespaceWith(oldLabels, ownerReferences),
+			))
+
+			Expect(review().Response.Allowed).To(BeTrue())
+			Expect(fakeProjectFetcher.GetProjectArgsForCall(0)).To(Equal("my-project"))
+		})
+
+		It("permits creating the namespace of the project that owns it", func() {
+			h.ServeHTTP(responseRecorder, testhelpers.CreateRequestForNamespaceWebhookAPI(http.MethodPost, "/namespace",
+				namespaceWith(oldLabels, ownerReferences),
+			))
+
+			Expect(review().Response.Allowed).To(BeTrue())
+		})
+
+		It("denies adding it to a namespace that the project does not own", func() {
+			h.ServeHTTP(responseRecorder, testhelpers.UpdateRequestForNamespaceWebhookAPI(http.MethodPost, "/namespace",
+				namespaceWith(withLabels(map[string]string{projects.ProjectLabel: ""}), nil),
+				namespaceWith(oldLabels, nil),
+			))
+
+			admissionReview := review()
+			Expect(admissionReview.Response.Allowed).To(BeFalse())
+			Expect(admissionReview.Response.Result.Message).To(Equal("label projects.vmware.com/project can only be set on the namespace of the project that owns it"))
+		})
+
+		It("denies creating a namespace with it that the project does not own", func() {
+			h.ServeHTTP(responseRecorder, testhelpers.CreateRequestForNamespaceWebhookAPI(http.MethodPost, "/namespace",
+				namespaceWith(oldLabels, nil),
+			))
+
+			Expect(review().Response.Allowed).To(BeFalse())
+		})
+
+		It("denies adding it with the owner reference of another project of the same name", func() {
+			ownerReferences[0].UID = "other-uid"
+			h.ServeHTTP(responseRecorder, testhelpers.UpdateRequestForNamespaceWebhookAPI(http.MethodPost, "/namespace",
+				namespaceWith(withLabels(map[string]string{projects.ProjectLabel: ""}), nil),
+				namespaceWith(oldLabels, ownerReferences),
+			))
+
+			Expect(review().Response.Allowed).To(BeFalse())
+		})
+
+		It("denies adding it to a namespace without a project", func() {
+			fakeProjectFetcher.GetProjectReturns(nil, nil)
+
+			h.ServeHTTP(responseRecorder, testhelpers.UpdateRequestForNamespaceWebhookAPI(http.MethodPost, "/namespace",
+				namespaceWith(withLabels(map[string]string{projects.ProjectLabel: ""}), nil),
+				namespaceWith(oldLabels, ownerReferences),
+			))
+
+			Expect(review().Response.Allowed).To(BeFalse())
+		})
+
+		It("denies adding it for a project with another name", func() {
+			fakeProjectFetcher.GetProjectReturns(nil, nil)
+
+			h.ServeHTTP(responseRecorder, testhelpers.UpdateRequestForNamespaceWebhookAPI(http.MethodPost, "/namespace",
+				namespaceWith(withLabels(map[string]string{projects.ProjectLabel: ""}), nil),
+				namespaceWith(withLabels(map[string]string{projects.ProjectLabel: "other-project"}), nil),
+			))
+
+			Expect(review().Response.Allowed).To(BeFalse())
+			Expect(fakeProjectFetcher.GetProjectCallCount()).To(BeZero())
+		})
+	})
+
+	When("the project is being deleted", func() {
+		It("permits the operator to release the namespace", func() {
+			deletionTimestamp := metav1.Now()
+			fakeProjectFetcher.GetProjectReturns(&projects.Project{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-project", DeletionTimestamp: &deletionTimestamp},
+				Spec: projects.ProjectSpec{
+					PodSecurity: &projects.PodSecurity{Enforce: projects.PodSecurityBaseline},
+				},
+			}, nil)
+
+			labels := map[string]string{"projects.vmware.com/former-project": "my-project"}
+			h.ServeHTTP(responseRecorder, testhelpers.ValidUpdateRequestForNamespaceWebhookAPI(http.MethodPost, "/namespace", "my-project", oldLabels, labels))
+
+			Expect(review().Response.Allowed).To(BeTrue())
+		})
+	})
+
+	It("permits changes to namespaces that are not owned by the project of the same name", func() {
+		labels := map[string]string{podsecurity.EnforceLabel: "privileged"}
+		h.ServeHTTP(responseRecorder, testhelpers.ValidUpdateRequestForNamespaceWebhookAPI(http.MethodPost, "/namespace", "my-project", map[string]string{}, labels))
+
+		Expect(review().Response.Allowed).To(BeTrue())
+		Expect(fakeProjectFetcher.GetProjectCallCount()).To(BeZero())
+	})
+
+	It("permits changes to namespaces without a project", func() {
+		fakeProjectFetcher.GetProjectReturns(nil, nil)
+
+		labels := withLabels(map[string]string{podsecurity.EnforceLabel: "privileged"})
+		h.ServeHTTP(responseRecorder, testhelpers.ValidUpdateRequestForNamespaceWebhookAPI(http.MethodPost, "/namespace", "my-project", oldLabels, labels))
+
+		Expect(review().Response.Allowed).To(BeTrue())
+	})
+
+	When("fetching the project fails", func() {
+		It("denies the admission with an internal error", func() {
+			fakeProjectFetcher.GetProjectReturns(nil, errors.New("boom"))
+
+			labels := withLabels(map[string]string{podsecurity.EnforceLabel: "privileged"})
+			h.ServeHTTP(responseRecorder, testhelpers.ValidUpdateRequestForNamespaceWebhookAPI(http.MethodPost, "/namespace", "my-project", oldLabels, labels))
+
+			admissionReview := review()
+			Expect(admissionReview.Response.Allowed).To(BeFalse())
+			Expect(admissionReview.Response.Result.Code).To(Equal(int32(http.StatusInternalServerError)))
+		})
+	})
+})
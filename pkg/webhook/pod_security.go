@@ -0,0 +1,58 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package webhook
+
+import (
+	"fmt"
+
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	"github.com/pivotal/projects-operator/pkg/podsecurity"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// validatePodSecurity returns an error for each pod security level of the
+// project that is more permissive than the configured maximum
+func (c Config) validatePodSecurity(project projects.Project) field.ErrorList {
+	if project.Spec.PodSecurity == nil {
+		return nil
+	}
+
+	levels := []struct {
+		mode  string
+		level projects.PodSecurityLevel
+	}{
+		{"enforce", project.Spec.PodSecurity.Enforce},
+		{"audit", project.Spec.PodSecurity.Audit},
+		{"warn", project.Spec.PodSecurity.Warn},
+	}
+
+	var errs field.ErrorList
+	for _, l := range levels {
+		if c.PodSecurity.Exceeds(l.level) {
+			path := field.NewPath("spec", "podSecurity", l.mode)
+			errs = append(errs, field.Invalid(path, l.level, fmt.Sprintf("more permissive than the maximum of '%s'", c.PodSecurity.Maximum)))
+		}
+	}
+
+	return errs
+}
+
+// loweredPodSecurityLabels returns a message for each pod security label of
+// the namespace that is more permissive than the project requires
+func (c Config) loweredPodSecurityLabels(project projects.Project, labels map[string]string) []string {
+	var messages []string
+
+	desired := c.PodSecurity.NamespaceLabels(project.Spec.PodSecurity)
+	for _, label := range podsecurity.ModeLabels {
+		level, ok := desired[label]
+		if !ok {
+			continue
+		}
+		if podsecurity.Weaker(projects.PodSecurityLevel(labels[label]), projects.PodSecurityLevel(level)) {
+			messages = append(messages, fmt.Sprintf("label %s of project namespace '%s' cannot be lower than '%s'", label, project.Name, level))
+		}
+	}
+
+	return messages
+}
@@ -0,0 +1,96 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package webhook
+
+import (
+	"sort"
+	"strings"
+
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+// normalizeAccess returns the canonical form of the access of a project:
+// service accounts are written as ServiceAccount subjects with a namespace,
+// the configured identity prefixes are stripped from users and groups, and
+// the subjects are deduplicated and sorted
+func (c Config) normalizeAccess(access []projects.SubjectRef) []projects.SubjectRef {
+	var normalized []projects.SubjectRef
+	for _, subject := range access {
+		subject = c.normalizeSubject(subject)
+
+		duplicate := false
+		for _, existing := range normalized {
+			if equality.Semantic.DeepEqual(existing, subject) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			normalized = append(normalized, subject)
+		}
+	}
+
+	sort.SliceStable(normalized, func(i, j int) bool {
+		a, b := normalized[i], normalized[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Role < b.Role
+	})
+
+	return normalized
+}
+
+func (c Config) normalizeSubject(subject projects.SubjectRef) projects.SubjectRef {
+	if subject.Kind == rbacv1.UserKind || subject.Kind == rbacv1.GroupKind {
+		for _, prefix := range c.IdentityPrefixes {
+			if prefix != "" && strings.HasPrefix(subject.Name, prefix) {
+				subject.Name = strings.TrimPrefix(subject.Name, prefix)
+				break
+			}
+		}
+	}
+
+	if subject.Kind == rbacv1.UserKind || subject.Kind == rbacv1.ServiceAccountKind {
+		if namespace, name, ok := serviceAccountFromUsername(subject.Name); ok && (subject.Namespace == "" || subject.Namespace == namespace) {
+			subject.Kind = rbacv1.ServiceAccountKind
+			subject.Namespace = namespace
+			subject.Name = name
+		}
+	}
+
+	if subject.Kind == rbacv1.ServiceAccountKind {
+		if subject.Namespace == "" {
+			subject.Namespace = corev1.NamespaceDefault
+		}
+	} else {
+		subject.Namespace = ""
+	}
+
+	return subject
+}
+
+// serviceAccountFromUsername splits the username of a service account into
+// its namespace and name
+func serviceAccountFromUsername(username string) (string, string, bool) {
+	if !strings.HasPrefix(username, serviceAccountUsernamePrefix) {
+		return "", "", false
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(username, serviceAccountUsernamePrefix), ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
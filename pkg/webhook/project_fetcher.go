@@ -7,6 +7,8 @@ import (
 	"context"
 
 	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -14,12 +16,16 @@ import (
 
 type ProjectFetcher interface {
 	GetProjects() ([]projects.Project, error)
+	GetProject(name string) (*projects.Project, error)
+	GetProjectsForUser(authenticationv1.UserInfo) ([]projects.Project, error)
 }
 
 type projectFetcher struct {
 	client client.Client
 }
 
+// NewProjectFetcher returns a fetcher that reads through the client. The
+// client must have the indexes added by IndexProjects.
 func NewProjectFetcher(client client.Client) *projectFetcher {
 	return &projectFetcher{
 		client: client,
@@ -32,3 +38,60 @@ func (f *projectFetcher) GetProjects() ([]projects.Project, error) {
 
 	return projectList.Items, err
 }
+
+// GetProject returns the named project, or nil if it does not exist
+func (f *projectFetcher) GetProject(name string) (*projects.Project, error) {
+	project := &projects.Project{}
+	if err := f.client.Get(context.TODO(), client.ObjectKey{Name: name}, project); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return project, nil
+}
+
+// GetProjectsForUser returns the projects whose access names the user or one
+// of their groups, together with all of their descendants
+func (f *projectFetcher) GetProjectsForUser(user authenticationv1.UserInfo) ([]projects.Project, error) {
+	var result []projects.Project
+	seen := map[string]bool{}
+
+	add := func(field, value string) ([]string, error) {
+		projectList := &projects.ProjectList{}
+		if err := f.client.List(context.TODO(), projectList, client.MatchingFields{field: value}); err != nil {
+			return nil, err
+		}
+
+		var added []string
+		for _, project := range projectList.Items {
+			if !seen[project.Name] {
+				seen[project.Name] = true
+				result = append(result, project)
+				added = append(added, project.Name)
+			}
+		}
+		return added, nil
+	}
+
+	var parents []string
+	for _, key := range userAccessKeys(user) {
+		added, err := add(AccessSubjectField, key)
+		if err != nil {
+			return nil, err
+		}
+		parents = append(parents, added...)
+	}
+
+	// descendants inherit the access of their ancestors
+	for len(parents) > 0 {
+		added, err := add(ParentField, parents[0])
+		if err != nil {
+			return nil, err
+		}
+		parents = append(parents[1:], added...)
+	}
+
+	return result, nil
+}
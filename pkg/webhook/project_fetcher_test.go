@@ -5,6 +5,7 @@ package webhook_test
 
 import (
 	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -20,22 +21,41 @@ var _ = Describe("ProjectFetcher", func() {
 		fakeClient client.Client
 	)
 
-	BeforeEach(func() {
-		projectA := &projects.Project{
+	project := func(name, parent string, access ...projects.SubjectRef) *projects.Project {
+		return &projects.Project{
 			ObjectMeta: metav1.ObjectMeta{
-				Name: "project-a",
+				Name: name,
+			},
+			Spec: projects.ProjectSpec{
+				Parent: parent,
+				Access: access,
 			},
 		}
+	}
 
-		projectB := &projects.Project{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: "project-b",
-			},
+	names := func(list []projects.Project) []string {
+		var result []string
+		for _, project := range list {
+			result = append(result, project.Name)
 		}
+		return result
+	}
 
+	BeforeEach(func() {
 		scheme, err := projects.SchemeBuilder.Build()
 		Expect(err).NotTo(HaveOccurred())
-		fakeClient = fake.NewFakeClientWithScheme(scheme, projectA, projectB)
+		fakeClient = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(
+				project("project-a", "", projects.SubjectRef{Kind: "User", Name: "alice"}),
+				project("project-b", "", projects.SubjectRef{Kind: "Group", Name: "group-a"}),
+				project("project-c", "project-a"),
+				project("project-d", "project-c"),
+				project("project-e", "", projects.SubjectRef{Kind: "ServiceAccount", Name: "robot", Namespace: "some-namespace"}),
+			).
+			WithIndex(&projects.Project{}, AccessSubjectField, AccessSubjectKeys).
+			WithIndex(&projects.Project{}, ParentField, ParentKeys).
+			Build()
 		fetcher = NewProjectFetcher(fakeClient)
 	})
 
@@ -43,8 +63,47 @@ var _ = Describe("ProjectFetcher", func() {
 		It("returns a list of projects", func() {
 			projects, err := fetcher.GetProjects()
 			Expect(err).NotTo(HaveOccurred())
-			Expect(projects).To(HaveLen(2))
-			Expect([]string{projects[0].ObjectMeta.Name, projects[1].ObjectMeta.Name}).To(ConsistOf("project-a", "project-b"))
+			Expect(names(projects)).To(ConsistOf("project-a", "project-b", "project-c", "project-d", "project-e"))
+		})
+	})
+
+	Describe("GetProject", func() {
+		It("returns the named project", func() {
+			project, err := fetcher.GetProject("project-b")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(project.Name).To(Equal("project-b"))
+		})
+
+		It("returns nil for a missing project", func() {
+			project, err := fetcher.GetProject("project-z")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(project).To(BeNil())
+		})
+	})
+
+	Describe("GetProjectsForUser", func() {
+		It("returns the projects naming the user and their descendants", func() {
+			projects, err := fetcher.GetProjectsForUser(authenticationv1.UserInfo{Username: "alice"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names(projects)).To(ConsistOf("project-a", "project-c", "project-d"))
+		})
+
+		It("returns the projects naming the groups of the user", func() {
+			projects, err := fetcher.GetProjectsForUser(authenticationv1.UserInfo{Username: "bob", Groups: []string{"group-a"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names(projects)).To(ConsistOf("project-b"))
+		})
+
+		It("returns the projects naming a service account", func() {
+			projects, err := fetcher.GetProjectsForUser(authenticationv1.UserInfo{Username: "system:serviceaccount:some-namespace:robot"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names(projects)).To(ConsistOf("project-e"))
+		})
+
+		It("returns nothing for a user without access", func() {
+			projects, err := fetcher.GetProjectsForUser(authenticationv1.UserInfo{Username: "mallory"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(projects).To(BeEmpty())
 		})
 	})
 })
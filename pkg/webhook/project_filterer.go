@@ -5,8 +5,11 @@ package webhook
 
 import (
 	"fmt"
+	"sort"
+	"time"
 
 	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	"github.com/pivotal/projects-operator/pkg/hierarchy"
 	authenticationv1 "k8s.io/api/authentication/v1"
 	corev1 "k8s.io/api/core/v1"
 )
@@ -14,59 +17,112 @@ import (
 //go:generate counterfeiter . ProjectFilterer
 
 type ProjectFilterer interface {
-	FilterProjects([]projects.Project, authenticationv1.UserInfo) []string
+	FilterProjects([]projects.Project, authenticationv1.UserInfo) ([]projects.AccessibleProject, error)
 }
 
-type projectFilterer struct{}
+type projectFilterer struct {
+	adminRoleProfiles []string
+}
+
+// NewProjectFilterer returns a filterer that treats subjects without a role
+// and subjects of the given role profiles as project admins
+func NewProjectFilterer(adminRoleProfiles ...string) projectFilterer {
+	return projectFilterer{adminRoleProfiles: adminRoleProfiles}
+}
 
-func NewProjectFilterer() projectFilterer {
-	return projectFilterer{}
+// grant is an entry in the access of a project
+type grant struct {
+	project    *projects.Project
+	subjectRef projects.SubjectRef
 }
 
-//TODO: return []v1alpha1.Project instead of []string ?
-func (f projectFilterer) FilterProjects(projects []projects.Project, user authenticationv1.UserInfo) []string {
-	groupProjectMap := make(map[string][]string)
-	usernameProjectMap := make(map[string][]string)
-	serviceAccountProjectMap := make(map[string][]string)
+func (f projectFilterer) FilterProjects(projectList []projects.Project, user authenticationv1.UserInfo) ([]projects.AccessibleProject, error) {
+	groupProjectMap := make(map[string][]grant)
+	usernameProjectMap := make(map[string][]grant)
+	serviceAccountProjectMap := make(map[string][]grant)
 
-	namespace := corev1.NamespaceDefault
+	now := time.Now()
+	byName := hierarchy.ByName(projectList)
+	for i := range projectList {
+		project := &projectList[i]
 
-	for _, project := range projects {
-		for _, access := range project.Spec.Access {
+		// a project in a cycle still grants the access resolved up to the cycle
+		effectiveAccess, _ := hierarchy.Access(*project, byName, now)
+		for _, access := range effectiveAccess {
+			g := grant{project: project, subjectRef: access}
 			switch kind := access.Kind; kind {
 			case "Group":
-				groupProjectMap[access.Name] = append(groupProjectMap[access.Name], project.Name)
+				groupProjectMap[access.Name] = append(groupProjectMap[access.Name], g)
 			case "User":
-				usernameProjectMap[access.Name] = append(usernameProjectMap[access.Name], project.Name)
+				usernameProjectMap[access.Name] = append(usernameProjectMap[access.Name], g)
 			case "ServiceAccount":
-				if access.Namespace != "" {
-					namespace = access.Namespace
+				namespace := access.Namespace
+				if namespace == "" {
+					namespace = corev1.NamespaceDefault
 				}
 				fullServiceAccountName := fmt.Sprintf("system:serviceaccount:%s:%s", namespace, access.Name)
-				serviceAccountProjectMap[fullServiceAccountName] = append(serviceAccountProjectMap[fullServiceAccountName], project.Name)
+				serviceAccountProjectMap[fullServiceAccountName] = append(serviceAccountProjectMap[fullServiceAccountName], g)
 			}
 		}
 	}
 
-	var filteredProjects []string
+	var matchedGrants []grant
+	matchedGrants = append(matchedGrants, usernameProjectMap[user.Username]...)
+	matchedGrants = append(matchedGrants, serviceAccountProjectMap[user.Username]...)
 	for _, group := range user.Groups {
-		filteredProjects = append(filteredProjects, groupProjectMap[group]...)
+		matchedGrants = append(matchedGrants, groupProjectMap[group]...)
+	}
+
+	return f.accessibleProjects(matchedGrants), nil
+}
+
+// accessibleProjects collects the matched grants of each project, sorted by
+// project name
+func (f projectFilterer) accessibleProjects(matchedGrants []grant) []projects.AccessibleProject {
+	byProject := map[string]*projects.AccessibleProject{}
+	for _, g := range matchedGrants {
+		accessible, ok := byProject[g.project.Name]
+		if !ok {
+			namespace := g.project.Status.Namespace
+			if namespace == "" {
+				namespace = g.project.Name
+			}
+			accessible = &projects.AccessibleProject{
+				Name:      g.project.Name,
+				Namespace: namespace,
+				Role:      g.subjectRef.Role,
+			}
+			byProject[g.project.Name] = accessible
+		}
+
+		accessible.Subjects = append(accessible.Subjects, g.subjectRef)
+		if !accessible.Admin && f.isAdminRole(g.subjectRef.Role) {
+			accessible.Admin = true
+			accessible.Role = g.subjectRef.Role
+		}
 	}
-	filteredProjects = append(filteredProjects, usernameProjectMap[user.Username]...)
-	filteredProjects = append(filteredProjects, serviceAccountProjectMap[user.Username]...)
 
-	return deduplicateStringSlice(filteredProjects)
+	var accessibleProjects []projects.AccessibleProject
+	for _, accessible := range byProject {
+		accessibleProjects = append(accessibleProjects, *accessible)
+	}
+	sort.Slice(accessibleProjects, func(i, j int) bool {
+		return accessibleProjects[i].Name < accessibleProjects[j].Name
+	})
+
+	return accessibleProjects
 }
 
-func deduplicateStringSlice(strs []string) []string {
-	set := make(map[string]struct{}, len(strs))
-	for _, str := range strs {
-		set[str] = struct{}{}
+func (f projectFilterer) isAdminRole(role string) bool {
+	if role == "" {
+		return true
 	}
-	var dedupedStrs []string
-	for key := range set {
-		dedupedStrs = append(dedupedStrs, key)
+
+	for _, adminRole := range f.adminRoleProfiles {
+		if role == adminRole {
+			return true
+		}
 	}
 
-	return dedupedStrs
+	return false
 }
@@ -4,6 +4,8 @@
 package webhook_test
 
 import (
+	"time"
+
 	projects "github.com/pivotal/projects-operator/api/v1alpha1"
 	authenticationv1 "k8s.io/api/authentication/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -19,9 +21,17 @@ var _ = Describe("ProjectFilterer", func() {
 		projectsToFilter []projects.Project
 		user             authenticationv1.UserInfo
 
-		filteredProjects []string
+		filteredProjects []projects.AccessibleProject
 	)
 
+	projectNames := func(accessibleProjects []projects.AccessibleProject) []string {
+		var names []string
+		for _, accessible := range accessibleProjects {
+			names = append(names, accessible.Name)
+		}
+		return names
+	}
+
 	BeforeEach(func() {
 		project1 := projects.Project{
 			ObjectMeta: metav1.ObjectMeta{
@@ -74,7 +84,9 @@ var _ = Describe("ProjectFilterer", func() {
 	})
 
 	JustBeforeEach(func() {
-		filteredProjects = filterer.FilterProjects(projectsToFilter, user)
+		var err error
+		filteredProjects, err = filterer.FilterProjects(projectsToFilter, user)
+		Expect(err).NotTo(HaveOccurred())
 	})
 
 	When("the user matches no projects", func() {
@@ -93,7 +105,7 @@ var _ = Describe("ProjectFilterer", func() {
 		})
 
 		It("returns the project that grants access to the user", func() {
-			Expect(filteredProjects).To(ConsistOf("project-1"))
+			Expect(projectNames(filteredProjects)).To(ConsistOf("project-1"))
 		})
 	})
 
@@ -103,7 +115,7 @@ var _ = Describe("ProjectFilterer", func() {
 		})
 
 		It("returns the project that grants access to the group", func() {
-			Expect(filteredProjects).To(ConsistOf("project-2"))
+			Expect(projectNames(filteredProjects)).To(ConsistOf("project-2"))
 		})
 	})
 
@@ -115,7 +127,7 @@ var _ = Describe("ProjectFilterer", func() {
 		})
 
 		It("returns the project that grants access to the service account", func() {
-			Expect(filteredProjects).To(ConsistOf("project-1"))
+			Expect(projectNames(filteredProjects)).To(ConsistOf("project-1"))
 		})
 	})
 
@@ -125,7 +137,7 @@ var _ = Describe("ProjectFilterer", func() {
 		})
 
 		It("returns all the matched projects", func() {
-			Expect(filteredProjects).To(ConsistOf("project-1", "project-2"))
+			Expect(projectNames(filteredProjects)).To(ConsistOf("project-1", "project-2"))
 		})
 	})
 
@@ -135,7 +147,7 @@ var _ = Describe("ProjectFilterer", func() {
 		})
 
 		It("returns the project once only", func() {
-			Expect(filteredProjects).To(ConsistOf("project-2"))
+			Expect(projectNames(filteredProjects)).To(ConsistOf("project-2"))
 		})
 	})
 
@@ -160,4 +172,113 @@ var _ = Describe("ProjectFilterer", func() {
 			Expect(filteredProjects).To(BeEmpty())
 		})
 	})
+
+	When("a service account does not have a namespace", func() {
+		BeforeEach(func() {
+			projectsToFilter[0].Spec.Access = append(projectsToFilter[0].Spec.Access, projects.SubjectRef{
+				Kind: "ServiceAccount",
+				Name: "service-account-3",
+			})
+		})
+
+		It("matches the service account of the default namespace", func() {
+			user = authenticationv1.UserInfo{Username: "system:serviceaccount:default:service-account-3"}
+			accessibleProjects, err := filterer.FilterProjects(projectsToFilter, user)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(projectNames(accessibleProjects)).To(ConsistOf("project-1"))
+		})
+
+		It("does not use the namespace of the previous service account", func() {
+			user = authenticationv1.UserInfo{Username: "system:serviceaccount:namespace-1:service-account-3"}
+			Expect(filterer.FilterProjects(projectsToFilter, user)).To(BeEmpty())
+		})
+	})
+
+	When("the user has access to a parent project", func() {
+		BeforeEach(func() {
+			child := projects.Project{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "project-3",
+				},
+				Spec: projects.ProjectSpec{
+					Parent: "project-1",
+				},
+			}
+			grandchild := projects.Project{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "project-4",
+				},
+				Spec: projects.ProjectSpec{
+					Parent: "project-3",
+				},
+			}
+			projectsToFilter = append(projectsToFilter, child, grandchild)
+
+			user = authenticationv1.UserInfo{Username: "other-developer", Groups: []string{"group-1"}}
+		})
+
+		It("returns the descendants of the project", func() {
+			Expect(projectNames(filteredProjects)).To(ConsistOf("project-1", "project-3", "project-4"))
+		})
+	})
+
+	When("the user's access has expired", func() {
+		BeforeEach(func() {
+			projectsToFilter[0].Spec.Access[0].ExpiresAt = &metav1.Time{Time: time.Now().Add(-time.Minute)}
+
+			user = authenticationv1.UserInfo{Username: "developer-1"}
+		})
+
+		It("returns no projects", func() {
+			Expect(filteredProjects).To(BeEmpty())
+		})
+	})
+
+	Describe("the accessible projects", func() {
+		BeforeEach(func() {
+			projectsToFilter[0].Status.Namespace = "namespace-of-project-1"
+			projectsToFilter[0].Spec.Access[1].Role = "view"
+			projectsToFilter[1].Spec.Access[1].Role = "view"
+			projectsToFilter[1].Spec.Access = append(projectsToFilter[1].Spec.Access, projects.SubjectRef{Kind: "Group", Name: "group-3", Role: "edit"})
+
+			filterer = NewProjectFilterer("edit")
+			user = authenticationv1.UserInfo{Username: "developer-1", Groups: []string{"group-1", "group-2", "group-3"}}
+		})
+
+		It("are sorted by name", func() {
+			Expect(projectNames(filteredProjects)).To(Equal([]string{"project-1", "project-2"}))
+		})
+
+		It("include the project namespace", func() {
+			Expect(filteredProjects[0].Namespace).To(Equal("namespace-of-project-1"))
+			Expect(filteredProjects[1].Namespace).To(Equal("project-2"))
+		})
+
+		It("include the matching subjects", func() {
+			Expect(filteredProjects[0].Subjects).To(Equal([]projects.SubjectRef{
+				{Kind: "User", Name: "developer-1"},
+				{Kind: "Group", Name: "group-1", Role: "view"},
+			}))
+		})
+
+		It("use the most privileged role", func() {
+			Expect(filteredProjects[0].Role).To(Equal(""))
+			Expect(filteredProjects[0].Admin).To(BeTrue())
+
+			Expect(filteredProjects[1].Role).To(Equal("edit"))
+			Expect(filteredProjects[1].Admin).To(BeTrue())
+		})
+
+		When("no matching subject is an admin", func() {
+			BeforeEach(func() {
+				user = authenticationv1.UserInfo{Username: "other-developer", Groups: []string{"group-2"}}
+			})
+
+			It("is not an admin", func() {
+				Expect(filteredProjects).To(HaveLen(1))
+				Expect(filteredProjects[0].Role).To(Equal("view"))
+				Expect(filteredProjects[0].Admin).To(BeFalse())
+			})
+		})
+	})
 })
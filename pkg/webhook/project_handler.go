@@ -7,23 +7,31 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"regexp"
+	"time"
 
 	"github.com/go-logr/logr"
 	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	"github.com/pivotal/projects-operator/pkg/hierarchy"
 	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
 type ProjectHandler struct {
 	NamespaceFetcher NamespaceFetcher
+	ProjectFetcher   ProjectFetcher
+	config           Config
 	logger           logr.Logger
 }
 
-func NewProjectHandler(logger logr.Logger, namespaceFetcher NamespaceFetcher) *ProjectHandler {
+func NewProjectHandler(logger logr.Logger, config Config, namespaceFetcher NamespaceFetcher, projectFetcher ProjectFetcher) *ProjectHandler {
 	return &ProjectHandler{
 		NamespaceFetcher: namespaceFetcher,
+		ProjectFetcher:   projectFetcher,
+		config:           config,
 		logger:           logger,
 	}
 }
@@ -34,8 +42,7 @@ func (h *ProjectHandler) HandleProjectValidation(w http.ResponseWriter, r *http.
 	// 1. Read the body
 	body, err := ensureBody(r.Body)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, `{"error": "%s"}`, err.Error())
+		sendError(w, nil, http.StatusBadRequest, fmt.Sprintf("error reading body: %s", err))
 
 		h.logger.Error(err, "error reading body")
 		return
@@ -44,8 +51,7 @@ func (h *ProjectHandler) HandleProjectValidation(w http.ResponseWriter, r *http.
 	// 2. Unmarshal to AdmissionReview
 	arRequest, err := unmarshalToAdmissionReview(body)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, `{"error unmarshalling request body": "%s"}`, err)
+		sendError(w, nil, http.StatusBadRequest, fmt.Sprintf("error unmarshalling request body: %s", err))
 
 		h.logger.Error(err, "error unmarshaling AdmissionReview")
 		return
@@ -55,129 +61,195 @@ func (h *ProjectHandler) HandleProjectValidation(w http.ResponseWriter, r *http.
 	raw := arRequest.Request.Object.Raw
 	project := projects.Project{}
 	if err := json.Unmarshal(raw, &project); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, `{"error unmarshalling project": "%s"}`, err)
+		sendError(w, arRequest, http.StatusBadRequest, fmt.Sprintf("error unmarshalling project: %s", err))
 
 		h.logger.Error(err, "error unmarshaling Project from AdmissionReview")
 		return
 	}
 
-	// 4. Get all current namespaces
-	namespaces, err := h.NamespaceFetcher.GetNamespaces()
+	var oldProject *projects.Project
+	if arRequest.Request.Operation == admissionv1.Update && len(arRequest.Request.OldObject.Raw) > 0 {
+		oldProject = &projects.Project{}
+		if err := json.Unmarshal(arRequest.Request.OldObject.Raw, oldProject); err != nil {
+			sendError(w, arRequest, http.StatusBadRequest, fmt.Sprintf("error unmarshalling old project: %s", err))
+
+			h.logger.Error(err, "error unmarshaling old Project from AdmissionReview")
+			return
+		}
+	}
+
+	// 4. Validate the fields of the project, comparing updates with the
+	// old project
+	errs, warnings := h.config.validateProject(project, oldProject, time.Now())
+
+	// 5. Determine if a namespace with the project name already exists,
+	// the namespace of an existing project always does. Only admins may
+	// adopt an existing namespace
+	exists, err := h.NamespaceFetcher.NamespaceExists(project.ObjectMeta.Name)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, `{"error fetching namespaces": "%s"}`, err.Error())
+		sendError(w, arRequest, http.StatusInternalServerError, fmt.Sprintf("error fetching namespaces: %s", err))
 
 		h.logger.Error(err, "error fetching Namespaces")
 		return
 	}
 
-	// 5. Do some logic to determine if a namespace with the project name already exists
-	allowed := true
-	for _, namespace := range namespaces {
-		if namespace.ObjectMeta.Name == project.ObjectMeta.Name {
-			allowed = false
+	if exists && arRequest.Request.Operation != admissionv1.Update {
+		switch {
+		case !project.AdoptsExistingNamespace():
+			errs = append(errs, field.Forbidden(field.NewPath("metadata", "name"), fmt.Sprintf("cannot create project over existing namespace '%s'", project.ObjectMeta.Name)))
+		case !h.isAdmin(arRequest.Request.UserInfo):
+			errs = append(errs, field.Forbidden(field.NewPath("spec", "adoptExistingNamespace"), fmt.Sprintf("only members of the admin groups may adopt existing namespace '%s'", project.ObjectMeta.Name)))
+		}
+	}
+
+	// 6. Ensure a new parent does not make the project its own ancestor
+	parentChanged := oldProject == nil || oldProject.Spec.Parent != project.Spec.Parent
+	if project.Spec.Parent != "" && parentChanged && project.DeletionTimestamp.IsZero() {
+		allProjects, err := h.ProjectFetcher.GetProjects()
+		if err != nil {
+			sendError(w, arRequest, http.StatusInternalServerError, fmt.Sprintf("error fetching projects: %s", err))
+
+			h.logger.Error(err, "error fetching Projects")
+			return
+		}
+
+		byName := hierarchy.ByName(allProjects)
+		byName[project.Name] = project
+		if _, err := hierarchy.Ancestors(project, byName); err != nil {
+			errs = append(errs, field.Invalid(field.NewPath("spec", "parent"), project.Spec.Parent, err.Error()))
 		}
 	}
 
-	// 6. Create a response
-	arReview := &admissionv1.AdmissionReview{
-		Response: &admissionv1.AdmissionResponse{
-			Allowed: allowed,
-			Result: &metav1.Status{
-				Status:  "Failure",
-				Message: fmt.Sprintf("cannot create project over existing namespace '%s'", project.ObjectMeta.Name),
-			},
-		},
+	// 7. Create a response listing every invalid field
+	response := &admissionv1.AdmissionResponse{
+		Allowed:  len(errs) == 0,
+		Warnings: warnings,
 	}
 
-	// 7. Send AdmissionReview
-	sendReview(w, arReview)
+	if len(errs) > 0 {
+		invalid := apierrors.NewInvalid(projects.GroupVersion.WithKind("Project").GroupKind(), project.Name, errs)
+		response.Result = &invalid.ErrStatus
+	}
+
+	// 8. Send AdmissionReview
+	sendReview(w, arRequest, response)
 }
 
-func (h *ProjectHandler) HandleProjectCreation(w http.ResponseWriter, r *http.Request) {
-	h.logger.Info("handling project create request")
+func (h *ProjectHandler) HandleProjectDefaulting(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("handling project defaulting request")
 
 	// 1. Read the body
 	body, err := ensureBody(r.Body)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, `{"error": "%s"}`, err.Error())
+		sendError(w, nil, http.StatusBadRequest, fmt.Sprintf("error reading body: %s", err))
 
 		h.logger.Error(err, "error reading body")
 		return
 	}
 
+	// 2. Unmarshal to AdmissionReview
 	arRequest, err := unmarshalToAdmissionReview(body)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, `{"error unmarshalling request body": "%s"}`, err)
+		sendError(w, nil, http.StatusBadRequest, fmt.Sprintf("error unmarshalling request body: %s", err))
 
 		h.logger.Error(err, "error unmarshaling AdmissionReview")
 		return
 	}
 
+	// 3. Unmarshal the admissionreview.object.raw into a v1alpha1.Project
 	raw := arRequest.Request.Object.Raw
 	project := projects.Project{}
 	if err := json.Unmarshal(raw, &project); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, `{"error unmarshalling Project": "%s"}`, err)
+		sendError(w, arRequest, http.StatusBadRequest, fmt.Sprintf("error unmarshalling Project: %s", err))
 
 		h.logger.Error(err, "error unmarshaling Project from AdmissionReview")
 		return
 	}
 
-	if len(project.Spec.Access) > 0 {
-		sendReview(w, &admissionv1.AdmissionReview{
-			Response: &admissionv1.AdmissionResponse{
-				Allowed: true,
-			},
-		})
-		return
+	var patch []PatchOperation
+
+	// 4. Add the requesting user to new projects without access and bring
+	// the access of every project into its canonical form
+	access := project.Spec.Access
+	if len(access) == 0 && arRequest.Request.Operation != admissionv1.Update {
+		access = []projects.SubjectRef{requestingSubject(arRequest.Request.UserInfo)}
 	}
 
-	userInfo := arRequest.Request.UserInfo
+	if normalized := h.config.normalizeAccess(access); !equality.Semantic.DeepEqual(normalized, project.Spec.Access) {
+		patch = append(patch, PatchOperation{
+			Op:    "add",
+			Path:  "/spec/access",
+			Value: interface{}(normalized),
+		})
+	}
 
-	var subjectRef projects.SubjectRef
-	if groups := regexp.MustCompile(`system:serviceaccount:([-a-z0-9]+):(.*)`).FindStringSubmatch(userInfo.Username); len(groups) == 3 {
-		subjectRef = projects.SubjectRef{
-			Kind:      rbacv1.ServiceAccountKind,
-			Namespace: groups[1],
-			Name:      groups[2],
+	// 5. Add the default quota and limits to new projects
+	if arRequest.Request.Operation != admissionv1.Update {
+		if quota := h.config.defaultQuota(project); quota != nil {
+			patch = append(patch, PatchOperation{
+				Op:    "add",
+				Path:  "/spec/quota",
+				Value: quota,
+			})
 		}
-	} else {
-		subjectRef = projects.SubjectRef{
-			Kind: rbacv1.UserKind,
-			Name: userInfo.Username,
+
+		if limits := h.config.defaultLimits(project); limits != nil {
+			patch = append(patch, PatchOperation{
+				Op:    "add",
+				Path:  "/spec/limits",
+				Value: limits,
+			})
 		}
 	}
 
-	patchBytes, err := createProjectPatch(subjectRef)
+	if len(patch) == 0 {
+		sendReview(w, arRequest, &admissionv1.AdmissionResponse{
+			Allowed: true,
+		})
+		return
+	}
+
+	patchBytes, err := json.Marshal(patch)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, `{"error creating Project patch": "%s"}`, err.Error())
+		sendError(w, arRequest, http.StatusInternalServerError, fmt.Sprintf("error creating Project patch: %s", err))
 
 		h.logger.Error(err, "error creating Project patch")
 		return
 	}
 
+	// 6. Send AdmissionReview
 	jsonPatchType := admissionv1.PatchTypeJSONPatch
-	arReview := &admissionv1.AdmissionReview{
-		Response: &admissionv1.AdmissionResponse{
-			Allowed:   true,
-			Patch:     patchBytes,
-			PatchType: &jsonPatchType,
-		},
+	sendReview(w, arRequest, &admissionv1.AdmissionResponse{
+		Allowed:   true,
+		Patch:     patchBytes,
+		PatchType: &jsonPatchType,
+	})
+}
+
+// requestingSubject returns the subject of the user making a request
+func requestingSubject(userInfo authenticationv1.UserInfo) projects.SubjectRef {
+	if namespace, name, ok := serviceAccountFromUsername(userInfo.Username); ok {
+		return projects.SubjectRef{
+			Kind:      rbacv1.ServiceAccountKind,
+			Namespace: namespace,
+			Name:      name,
+		}
 	}
 
-	// 8. Send AdmissionReview
-	sendReview(w, arReview)
+	return projects.SubjectRef{
+		Kind: rbacv1.UserKind,
+		Name: userInfo.Username,
+	}
 }
 
-func createProjectPatch(user projects.SubjectRef) ([]byte, error) {
-	return json.Marshal([]PatchOperation{{
-		Op:    "add",
-		Path:  "/spec/access",
-		Value: interface{}([]projects.SubjectRef{user}),
-	}})
+func (h *ProjectHandler) isAdmin(userInfo authenticationv1.UserInfo) bool {
+	for _, group := range userInfo.Groups {
+		for _, adminGroup := range h.config.AdminGroups {
+			if group == adminGroup {
+				return true
+			}
+		}
+	}
+
+	return false
 }
@@ -10,11 +10,17 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	"github.com/pivotal/projects-operator/pkg/podsecurity"
 	"github.com/pivotal/projects-operator/testhelpers"
 	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -36,24 +42,15 @@ var _ = Describe("ProjectHandler", func() {
 		responseRecorder = httptest.NewRecorder()
 
 		fakeNamespaceFetcher = new(webhookfakes.FakeNamespaceFetcher)
-		fakeNamespaceFetcher.GetNamespacesReturns([]corev1.Namespace{
-			corev1.Namespace{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "my-namespace-a",
-				},
-			},
-			corev1.Namespace{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "my-namespace-b",
-				},
-			},
-		}, nil)
+		fakeNamespaceFetcher.NamespaceExistsStub = func(name string) (bool, error) {
+			return name == "my-namespace-a" || name == "my-namespace-b", nil
+		}
 
 		fakeProjectFilterer = new(webhookfakes.FakeProjectFilterer)
-		fakeProjectFilterer.FilterProjectsReturns([]string{"my-project-a", "my-project-c"})
+		fakeProjectFilterer.FilterProjectsReturns([]projects.AccessibleProject{{Name: "my-project-a"}, {Name: "my-project-c"}}, nil)
 
 		logger := logr.Discard()
-		h = NewHandler(logger, fakeNamespaceFetcher, nil, nil)
+		h = NewHandler(logger, Config{}, fakeNamespaceFetcher, nil, nil, nil)
 	})
 
 	It("handles POST /project", func() {
@@ -68,10 +65,11 @@ var _ = Describe("ProjectHandler", func() {
 		Expect(responseRecorder.Result().StatusCode).To(Equal(http.StatusOK))
 	})
 
-	It("fetches all namespaces", func() {
+	It("looks up the namespace of the project", func() {
 		h.ServeHTTP(responseRecorder, testhelpers.ValidRequestForProjectWebhookAPI(http.MethodPost, "/project", "my-project", false))
 
-		Expect(fakeNamespaceFetcher.GetNamespacesCallCount()).To(Equal(1))
+		Expect(fakeNamespaceFetcher.NamespaceExistsCallCount()).To(Equal(1))
+		Expect(fakeNamespaceFetcher.NamespaceExistsArgsForCall(0)).To(Equal("my-project"))
 	})
 
 	When("the project name does not match an existing namespace", func() {
@@ -99,23 +97,88 @@ var _ = Describe("ProjectHandler", func() {
 			Expect(json.Unmarshal(response, &admissionReview)).To(Succeed())
 
 			Expect(admissionReview.Response.Allowed).To(BeFalse())
-			Expect(admissionReview.Response.Result.Message).To(Equal("cannot create project over existing namespace 'my-namespace-a'"))
+			Expect(admissionReview.Response.Result.Message).To(Equal(`Project.projects.vmware.com "my-namespace-a" is invalid: metadata.name: Forbidden: cannot create project over existing namespace 'my-namespace-a'`))
+		})
+	})
+
+	When("an existing project is updated", func() {
+		It("permits the admission", func() {
+			request := testhelpers.ValidRequestForProjectWebhookAPI(http.MethodPost, "/project", "my-namespace-a", false)
+			h.ServeHTTP(responseRecorder, testhelpers.WithOperation(request, admissionv1.Update))
+
+			response, err := ioutil.ReadAll(responseRecorder.Result().Body)
+			Expect(err).NotTo(HaveOccurred())
+
+			var admissionReview *admissionv1.AdmissionReview
+			Expect(json.Unmarshal(response, &admissionReview)).To(Succeed())
+
+			Expect(admissionReview.Response.Allowed).To(BeTrue())
+		})
+	})
+
+	When("the project adopts an existing namespace", func() {
+		It("denies the admission if the user is not an admin", func() {
+			h.ServeHTTP(responseRecorder, testhelpers.ValidRequestForAdoptingProjectWebhookAPI(http.MethodPost, "/project", "my-namespace-a"))
+
+			response, err := ioutil.ReadAll(responseRecorder.Result().Body)
+			Expect(err).NotTo(HaveOccurred())
+
+			var admissionReview *admissionv1.AdmissionReview
+			Expect(json.Unmarshal(response, &admissionReview)).To(Succeed())
+
+			Expect(admissionReview.Response.Allowed).To(BeFalse())
+			Expect(admissionReview.Response.Result.Message).To(Equal(`Project.projects.vmware.com "my-namespace-a" is invalid: spec.adoptExistingNamespace: Forbidden: only members of the admin groups may adopt existing namespace 'my-namespace-a'`))
+		})
+
+		When("the user is in an admin group", func() {
+			BeforeEach(func() {
+				h = NewHandler(logr.Discard(), Config{AdminGroups: []string{"group-a"}}, fakeNamespaceFetcher, nil, nil, nil)
+			})
+
+			It("permits the admission", func() {
+				h.ServeHTTP(responseRecorder, testhelpers.ValidRequestForAdoptingProjectWebhookAPI(http.MethodPost, "/project", "my-namespace-a"))
+
+				response, err := ioutil.ReadAll(responseRecorder.Result().Body)
+				Expect(err).NotTo(HaveOccurred())
+
+				var admissionReview *admissionv1.AdmissionReview
+				Expect(json.Unmarshal(response, &admissionReview)).To(Succeed())
+
+				Expect(admissionReview.Response.Allowed).To(BeTrue())
+			})
+
+			It("still denies projects that do not ask to adopt the namespace", func() {
+				h.ServeHTTP(responseRecorder, testhelpers.ValidRequestForProjectWebhookAPI(http.MethodPost, "/project", "my-namespace-a", false))
+
+				response, err := ioutil.ReadAll(responseRecorder.Result().Body)
+				Expect(err).NotTo(HaveOccurred())
+
+				var admissionReview *admissionv1.AdmissionReview
+				Expect(json.Unmarshal(response, &admissionReview)).To(Succeed())
+
+				Expect(admissionReview.Response.Allowed).To(BeFalse())
+			})
 		})
 	})
 
 	When("the NamespaceFetcher returns an error", func() {
 		BeforeEach(func() {
-			fakeNamespaceFetcher.GetNamespacesReturns([]corev1.Namespace{}, errors.New("error-fetching-namespaces"))
+			fakeNamespaceFetcher.NamespaceExistsReturns(false, errors.New("error-fetching-namespaces"))
 		})
 
-		It("returns an internal server error", func() {
+		It("denies the admission with an internal error", func() {
 			h.ServeHTTP(responseRecorder, testhelpers.ValidRequestForProjectWebhookAPI(http.MethodPost, "/project", "my-project", false))
 
-			body, err := ioutil.ReadAll(responseRecorder.Result().Body)
+			response, err := ioutil.ReadAll(responseRecorder.Result().Body)
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(string(body)).To(ContainSubstring("error fetching namespaces"))
-			Expect(responseRecorder.Result().StatusCode).To(Equal(http.StatusInternalServerError))
+			var admissionReview *admissionv1.AdmissionReview
+			Expect(json.Unmarshal(response, &admissionReview)).To(Succeed())
+
+			Expect(responseRecorder.Result().StatusCode).To(Equal(http.StatusOK))
+			Expect(admissionReview.Response.Allowed).To(BeFalse())
+			Expect(admissionReview.Response.Result.Code).To(Equal(int32(http.StatusInternalServerError)))
+			Expect(admissionReview.Response.Result.Message).To(Equal("error fetching namespaces: error-fetching-namespaces"))
 		})
 	})
 
@@ -180,4 +243,486 @@ var _ = Describe("ProjectHandler", func() {
 			Expect(admissionReview.Response.Patch).To(BeNil())
 		})
 	})
+
+	Describe("defaulting", func() {
+		BeforeEach(func() {
+			h = NewHandler(logr.Discard(), Config{IdentityPrefixes: []string{"oidc:"}}, fakeNamespaceFetcher, nil, nil, nil)
+		})
+
+		review := func() *admissionv1.AdmissionReview {
+			response, err := ioutil.ReadAll(responseRecorder.Result().Body)
+			Expect(err).NotTo(HaveOccurred())
+
+			var admissionReview *admissionv1.AdmissionReview
+			Expect(json.Unmarshal(response, &admissionReview)).To(Succeed())
+
+			return admissionReview
+		}
+
+		It("normalizes the access of updated projects", func() {
+			spec := projects.ProjectSpec{Access: []projects.SubjectRef{
+				{Kind: rbacv1.UserKind, Name: "oidc:bob"},
+				{Kind: rbacv1.ServiceAccountKind, Name: "deployer"},
+				{Kind: rbacv1.UserKind, Name: "system:serviceaccount:ci:builder"},
+				{Kind: rbacv1.GroupKind, Name: "oidc:team", Namespace: "ignored"},
+				{Kind: rbacv1.UserKind, Name: "bob"},
+				{Kind: rbacv1.UserKind, Name: "alice", Role: "viewer"},
+			}}
+			request := testhelpers.ValidRequestWithSpecForProjectWebhookAPI(http.MethodPost, "/project-create", "my-project", spec)
+			h.ServeHTTP(responseRecorder, testhelpers.WithOperation(request, admissionv1.Update))
+
+			admissionReview := review()
+			Expect(admissionReview.Response.Allowed).To(BeTrue())
+			Expect(string(admissionReview.Response.Patch)).To(Equal(`[{"op":"add","path":"/spec/access","value":[` +
+				`{"kind":"Group","name":"team"},` +
+				`{"kind":"ServiceAccount","name":"builder","namespace":"ci"},` +
+				`{"kind":"ServiceAccount","name":"deployer","namespace":"default"},` +
+				`{"kind":"User","name":"alice","role":"viewer"},` +
+				`{"kind":"User","name":"bob"}]}]`))
+		})
+
+		It("keeps subjects that differ by role for the validation to reject", func() {
+			spec := projects.ProjectSpec{Access: []projects.SubjectRef{
+				{Kind: rbacv1.UserKind, Name: "alice"},
+				{Kind: rbacv1.UserKind, Name: "alice", Role: "viewer"},
+			}}
+			h.ServeHTTP(responseRecorder, testhelpers.ValidRequestWithSpecForProjectWebhookAPI(http.MethodPost, "/project-create", "my-project", spec))
+
+			Expect(review().Response.Patch).To(BeNil())
+		})
+
+		It("does not add the requesting user or the defaults to updated projects", func() {
+			h = NewHandler(logr.Discard(), Config{DefaultQuota: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("10")}}, fakeNamespaceFetcher, nil, nil, nil)
+			request := testhelpers.ValidRequestForProjectWebhookAPI(http.MethodPost, "/project-create", "my-project", false)
+			h.ServeHTTP(responseRecorder, testhelpers.WithOperation(request, admissionv1.Update))
+
+			admissionReview := review()
+			Expect(admissionReview.Response.Allowed).To(BeTrue())
+			Expect(admissionReview.Response.Patch).To(BeNil())
+		})
+	})
+
+	Describe("quota", func() {
+		var config Config
+
+		BeforeEach(func() {
+			config = Config{
+				DefaultQuota:           corev1.ResourceList{corev1.ResourcePods: resource.MustParse("10"), corev1.ResourceRequestsCPU: resource.MustParse("2")},
+				MaxQuota:               corev1.ResourceList{corev1.ResourcePods: resource.MustParse("50")},
+				DefaultContainerLimits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")},
+				MaxContainerLimits:     corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+			}
+		})
+
+		JustBeforeEach(func() {
+			h = NewHandler(logr.Discard(), config, fakeNamespaceFetcher, nil, nil, nil)
+		})
+
+		review := func() *admissionv1.AdmissionReview {
+			response, err := ioutil.ReadAll(responseRecorder.Result().Body)
+			Expect(err).NotTo(HaveOccurred())
+
+			var admissionReview *admissionv1.AdmissionReview
+			Expect(json.Unmarshal(response, &admissionReview)).To(Succeed())
+
+			return admissionReview
+		}
+
+		When("creating a project", func() {
+			It("adds the default quota and limits", func() {
+				h.ServeHTTP(responseRecorder, testhelpers.ValidRequestWithQuotaForProjectWebhookAPI(http.MethodPost, "/project-create", "my-project", nil, nil))
+
+				admissionReview := review()
+				Expect(admissionReview.Response.Allowed).To(BeTrue())
+				Expect(string(admissionReview.Response.Patch)).To(ContainSubstring(`{"op":"add","path":"/spec/quota","value":{"hard":{"pods":"10","requests.cpu":"2"}}}`))
+				Expect(string(admissionReview.Response.Patch)).To(ContainSubstring(`{"op":"add","path":"/spec/limits","value":[{"type":"Container","default":{"memory":"256Mi"}}]}`))
+			})
+
+			It("keeps the quota and limits that are set", func() {
+				quota := &corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("20")}}
+				limits := []corev1.LimitRangeItem{{Type: corev1.LimitTypeContainer, Max: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")}}}
+				h.ServeHTTP(responseRecorder, testhelpers.ValidRequestWithQuotaForProjectWebhookAPI(http.MethodPost, "/project-create", "my-project", quota, limits))
+
+				admissionReview := review()
+				Expect(admissionReview.Response.Allowed).To(BeTrue())
+				Expect(string(admissionReview.Response.Patch)).To(ContainSubstring(`{"op":"add","path":"/spec/quota","value":{"hard":{"pods":"20","requests.cpu":"2"}}}`))
+				Expect(string(admissionReview.Response.Patch)).NotTo(ContainSubstring(`/spec/limits`))
+			})
+		})
+
+		When("validating a project", func() {
+			It("permits quotas and limits within the maximums", func() {
+				quota := &corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("50")}}
+				h.ServeHTTP(responseRecorder, testhelpers.ValidRequestWithQuotaForProjectWebhookAPI(http.MethodPost, "/project", "my-project", quota, nil))
+
+				Expect(review().Response.Allowed).To(BeTrue())
+			})
+
+			It("denies quotas above the maximum", func() {
+				quota := &corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("51")}}
+				h.ServeHTTP(responseRecorder, testhelpers.ValidRequestWithQuotaForProjectWebhookAPI(http.MethodPost, "/project", "my-project", quota, nil))
+
+				admissionReview := review()
+				Expect(admissionReview.Response.Allowed).To(BeFalse())
+				Expect(admissionReview.Response.Result.Message).To(Equal(`Project.projects.vmware.com "my-project" is invalid: spec.quota.hard[pods]: Invalid value: "51": exceeds the maximum of 50`))
+			})
+
+			It("denies quotas that do not set a capped resource", func() {
+				h.ServeHTTP(responseRecorder, testhelpers.ValidRequestWithQuotaForProjectWebhookAPI(http.MethodPost, "/project", "my-project", nil, nil))
+
+				admissionReview := review()
+				Expect(admissionReview.Response.Allowed).To(BeFalse())
+				Expect(admissionReview.Response.Result.Message).To(Equal(`Project.projects.vmware.com "my-project" is invalid: spec.quota.hard[pods]: Required value: must be set to at most 50`))
+			})
+
+			It("denies container limits above the maximum", func() {
+				quota := &corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("10")}}
+				limits := []corev1.LimitRangeItem{{Type: corev1.LimitTypeContainer, Default: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")}}}
+				h.ServeHTTP(responseRecorder, testhelpers.ValidRequestWithQuotaForProjectWebhookAPI(http.MethodPost, "/project", "my-project", quota, limits))
+
+				admissionReview := review()
+				Expect(admissionReview.Response.Allowed).To(BeFalse())
+				Expect(admissionReview.Response.Result.Message).To(Equal(`Project.projects.vmware.com "my-project" is invalid: spec.limits[0].default[memory]: Invalid value: "2Gi": exceeds the maximum of 1Gi`))
+			})
+		})
+
+		When("updating a project created before the maximums", func() {
+			var oldProject projects.Project
+
+			BeforeEach(func() {
+				oldProject = projects.Project{ObjectMeta: metav1.ObjectMeta{Name: "my-project"}}
+			})
+
+			It("permits metadata-only updates of a project without quota", func() {
+				project := *oldProject.DeepCopy()
+				project.Finalizers = []string{"projects.vmware.com/finalizer"}
+				h.ServeHTTP(responseRecorder, testhelpers.UpdateRequestForProjectWebhookAPI(http.MethodPost, "/project", oldProject, project))
+
+				Expect(review().Response.Allowed).To(BeTrue())
+			})
+
+			It("permits changing the limits without setting the quota", func() {
+				project := *oldProject.DeepCopy()
+				project.Spec.Limits = []corev1.LimitRangeItem{{Type: corev1.LimitTypeContainer, Max: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")}}}
+				h.ServeHTTP(responseRecorder, testhelpers.UpdateRequestForProjectWebhookAPI(http.MethodPost, "/project", oldProject, project))
+
+				Expect(review().Response.Allowed).To(BeTrue())
+			})
+
+			It("permits the update of a project being deleted", func() {
+				project := *oldProject.DeepCopy()
+				project.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+				project.Spec.Quota = &corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("100")}}
+				h.ServeHTTP(responseRecorder, testhelpers.UpdateRequestForProjectWebhookAPI(http.MethodPost, "/project", oldProject, project))
+
+				Expect(review().Response.Allowed).To(BeTrue())
+			})
+
+			It("denies a changed quota above the maximum", func() {
+				project := *oldProject.DeepCopy()
+				project.Spec.Quota = &corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("100")}}
+				h.ServeHTTP(responseRecorder, testhelpers.UpdateRequestForProjectWebhookAPI(http.MethodPost, "/project", oldProject, project))
+
+				admissionReview := review()
+				Expect(admissionReview.Response.Allowed).To(BeFalse())
+				Expect(admissionReview.Response.Result.Message).To(Equal(`Project.projects.vmware.com "my-project" is invalid: spec.quota.hard[pods]: Invalid value: "100": exceeds the maximum of 50`))
+			})
+		})
+	})
+
+	Describe("pod security", func() {
+		BeforeEach(func() {
+			config := Config{PodSecurity: podsecurity.Config{Maximum: projects.PodSecurityBaseline}}
+			h = NewHandler(logr.Discard(), config, fakeNamespaceFetcher, nil, nil, nil)
+		})
+
+		review := func() *admissionv1.AdmissionReview {
+			response, err := ioutil.ReadAll(responseRecorder.Result().Body)
+			Expect(err).NotTo(HaveOccurred())
+
+			var admissionReview *admissionv1.AdmissionReview
+			Expect(json.Unmarshal(response, &admissionReview)).To(Succeed())
+
+			return admissionReview
+		}
+
+		It("permits levels within the maximum", func() {
+			podSecurity := &projects.PodSecurity{Enforce: projects.PodSecurityBaseline, Warn: projects.PodSecurityRestricted}
+			h.ServeHTTP(responseRecorder, testhelpers.ValidRequestWithPodSecurityForProjectWebhookAPI(http.MethodPost, "/project", "my-project", podSecurity))
+
+			Expect(review().Response.Allowed).To(BeTrue())
+		})
+
+		It("denies levels more permissive than the maximum", func() {
+			podSecurity := &projects.PodSecurity{Enforce: projects.PodSecurityPrivileged}
+			h.ServeHTTP(responseRecorder, testhelpers.ValidRequestWithPodSecurityForProjectWebhookAPI(http.MethodPost, "/project", "my-project", podSecurity))
+
+			admissionReview := review()
+			Expect(admissionReview.Response.Allowed).To(BeFalse())
+			Expect(admissionReview.Response.Result.Message).To(Equal(`Project.projects.vmware.com "my-project" is invalid: spec.podSecurity.enforce: Invalid value: "privileged": more permissive than the maximum of 'baseline'`))
+		})
+	})
+
+	Describe("validation", func() {
+		var config Config
+
+		BeforeEach(func() {
+			config = Config{OperatorNamespace: "projects-operator", MaxMembers: 2}
+		})
+
+		JustBeforeEach(func() {
+			h = NewHandler(logr.Discard(), config, fakeNamespaceFetcher, nil, nil, nil)
+		})
+
+		review := func() *admissionv1.AdmissionReview {
+			response, err := ioutil.ReadAll(responseRecorder.Result().Body)
+			Expect(err).NotTo(HaveOccurred())
+
+			var admissionReview *admissionv1.AdmissionReview
+			Expect(json.Unmarshal(response, &admissionReview)).To(Succeed())
+
+			return admissionReview
+		}
+
+		user := func(name string) projects.SubjectRef {
+			return projects.SubjectRef{Kind: rbacv1.UserKind, Name: name}
+		}
+
+		It("permits a valid project", func() {
+			spec := projects.ProjectSpec{Access: []projects.SubjectRef{user("alice"), {Kind: rbacv1.ServiceAccountKind, Namespace: "ci", Name: "deployer"}}}
+			h.ServeHTTP(responseRecorder, testhelpers.ValidRequestWithSpecForProjectWebhookAPI(http.MethodPost, "/project", "my-project", spec))
+
+			admissionReview := review()
+			Expect(admissionReview.Response.Allowed).To(BeTrue())
+			Expect(admissionReview.Response.Warnings).To(BeEmpty())
+		})
+
+		DescribeTable("denies invalid and reserved names",
+			func(name, message string) {
+				spec := projects.ProjectSpec{Access: []projects.SubjectRef{user("alice")}}
+				h.ServeHTTP(responseRecorder, testhelpers.ValidRequestWithSpecForProjectWebhookAPI(http.MethodPost, "/project", name, spec))
+
+				admissionReview := review()
+				Expect(admissionReview.Response.Allowed).To(BeFalse())
+				Expect(admissionReview.Response.Result.Message).To(ContainSubstring(message))
+			},
+			Entry("uppercase letters", "My-Project", "metadata.name: Invalid value: \"My-Project\": a lowercase RFC 1123 label"),
+			Entry("too long", strings.Repeat("a", 64), "must be no more than 63 characters"),
+			Entry("kube- prefix", "kube-system", "metadata.name: Forbidden: 'kube-system' is a reserved namespace name"),
+			Entry("default", "default", "metadata.name: Forbidden: 'default' is a reserved namespace name"),
+			Entry("operator namespace", "projects-operator", "metadata.name: Forbidden: 'projects-operator' is a reserved namespace name"),
+		)
+
+		It("requires the namespace of service account subjects", func() {
+			spec := projects.ProjectSpec{Access: []projects.SubjectRef{{Kind: rbacv1.ServiceAccountKind, Name: "deployer"}}}
+			h.ServeHTTP(responseRecorder, testhelpers.ValidRequestWithSpecForProjectWebhookAPI(http.MethodPost, "/project", "my-project", spec))
+
+			admissionReview := review()
+			Expect(admissionReview.Response.Allowed).To(BeFalse())
+			Expect(admissionReview.Response.Result.Message).To(Equal(`Project.projects.vmware.com "my-project" is invalid: spec.access[0].namespace: Required value: required for ServiceAccount subjects`))
+		})
+
+		It("denies duplicate subjects", func() {
+			spec := projects.ProjectSpec{Access: []projects.SubjectRef{user("alice"), {Kind: rbacv1.UserKind, Name: "alice", ExpiresAt: &metav1.Time{Time: time.Now().Add(time.Hour)}}}}
+			h.ServeHTTP(responseRecorder, testhelpers.ValidRequestWithSpecForProjectWebhookAPI(http.MethodPost, "/project", "my-project", spec))
+
+			admissionReview := review()
+			Expect(admissionReview.Response.Allowed).To(BeFalse())
+			Expect(admissionReview.Response.Result.Message).To(Equal(`Project.projects.vmware.com "my-project" is invalid: spec.access[1]: Duplicate value: "User alice"`))
+		})
+
+		It("denies a subject listed twice with the same role", func() {
+			viewer := projects.SubjectRef{Kind: rbacv1.UserKind, Name: "alice", Role: "viewer"}
+			expiringViewer := viewer
+			expiringViewer.ExpiresAt = &metav1.Time{Time: time.Now().Add(time.Hour)}
+			spec := projects.ProjectSpec{Access: []projects.SubjectRef{viewer, expiringViewer}}
+			h.ServeHTTP(responseRecorder, testhelpers.ValidRequestWithSpecForProjectWebhookAPI(http.MethodPost, "/project", "my-project", spec))
+
+			admissionReview := review()
+			Expect(admissionReview.Response.Allowed).To(BeFalse())
+			Expect(admissionReview.Response.Result.Message).To(Equal(`Project.projects.vmware.com "my-project" is invalid: spec.access[1]: Duplicate value: "User alice with role viewer"`))
+		})
+
+		It("allows a subject to be listed once for each role", func() {
+			spec := projects.ProjectSpec{Access: []projects.SubjectRef{user("alice"), {Kind: rbacv1.UserKind, Name: "alice", Role: "viewer"}}}
+			h.ServeHTTP(responseRecorder, testhelpers.ValidRequestWithSpecForProjectWebhookAPI(http.MethodPost, "/project", "my-project", spec))
+
+			Expect(review().Response.Allowed).To(BeTrue())
+		})
+
+		It("caps the number of members", func() {
+			spec := projects.ProjectSpec{Access: []projects.SubjectRef{user("alice"), user("bob"), user("carol")}}
+			h.ServeHTTP(responseRecorder, testhelpers.ValidRequestWithSpecForProjectWebhookAPI(http.MethodPost, "/project", "my-project", spec))
+
+			admissionReview := review()
+			Expect(admissionReview.Response.Allowed).To(BeFalse())
+			Expect(admissionReview.Response.Result.Message).To(Equal(`Project.projects.vmware.com "my-project" is invalid: spec.access: Too many: 3: must have at most 2 items`))
+		})
+
+		It("lists every invalid field at once", func() {
+			spec := projects.ProjectSpec{Access: []projects.SubjectRef{user("alice"), user("alice"), {Kind: rbacv1.ServiceAccountKind, Name: "deployer"}}}
+			request := testhelpers.ValidRequestWithSpecForProjectWebhookAPI(http.MethodPost, "/project", "kube-project", spec)
+			h.ServeHTTP(responseRecorder, testhelpers.WithOperation(request, admissionv1.Update))
+
+			admissionReview := review()
+			Expect(admissionReview.Response.Allowed).To(BeFalse())
+			Expect(admissionReview.Response.Result.Code).To(Equal(int32(http.StatusUnprocessableEntity)))
+			Expect(admissionReview.Response.Result.Reason).To(Equal(metav1.StatusReasonInvalid))
+
+			var fields []string
+			for _, cause := range admissionReview.Response.Result.Details.Causes {
+				fields = append(fields, cause.Field)
+			}
+			Expect(fields).To(Equal([]string{"metadata.name", "spec.access", "spec.access[1]", "spec.access[2].namespace"}))
+		})
+
+		DescribeTable("warns about suspicious subjects",
+			func(subject projects.SubjectRef, warning string) {
+				spec := projects.ProjectSpec{Access: []projects.SubjectRef{subject}}
+				h.ServeHTTP(responseRecorder, testhelpers.ValidRequestWithSpecForProjectWebhookAPI(http.MethodPost, "/project", "my-project", spec))
+
+				admissionReview := review()
+				Expect(admissionReview.Response.Allowed).To(BeTrue())
+				Expect(admissionReview.Response.Warnings).To(ConsistOf(warning))
+			},
+			Entry("namespace of a user",
+				projects.SubjectRef{Kind: rbacv1.UserKind, Name: "alice", Namespace: "ci"},
+				"spec.access[0].namespace: namespace is ignored for User subjects"),
+			Entry("service account as a user",
+				user("system:serviceaccount:ci:deployer"),
+				"spec.access[0]: user 'system:serviceaccount:ci:deployer' is a service account, use kind ServiceAccount instead"),
+			Entry("expired access",
+				projects.SubjectRef{Kind: rbacv1.UserKind, Name: "alice", ExpiresAt: &metav1.Time{Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}},
+				"spec.access[0]: access of User alice already expired at 2020-01-01T00:00:00Z"),
+			Entry("access that never starts",
+				projects.SubjectRef{Kind: rbacv1.UserKind, Name: "alice", NotBefore: &metav1.Time{Time: time.Now().Add(2 * time.Hour)}, ExpiresAt: &metav1.Time{Time: time.Now().Add(time.Hour)}},
+				"spec.access[0]: notBefore is not before expiresAt, User alice never has access"),
+		)
+
+		When("updating a project created before the rules", func() {
+			var oldProject projects.Project
+
+			BeforeEach(func() {
+				oldProject = projects.Project{
+					ObjectMeta: metav1.ObjectMeta{Name: "kube-legacy"},
+					Spec: projects.ProjectSpec{Access: []projects.SubjectRef{
+						user("alice"), user("alice"), user("bob"), {Kind: rbacv1.ServiceAccountKind, Name: "deployer"},
+					}},
+				}
+			})
+
+			update := func(project projects.Project) *admissionv1.AdmissionReview {
+				h.ServeHTTP(responseRecorder, testhelpers.UpdateRequestForProjectWebhookAPI(http.MethodPost, "/project", oldProject, project))
+				return review()
+			}
+
+			It("permits metadata-only updates", func() {
+				project := *oldProject.DeepCopy()
+				project.Labels = map[string]string{"team": "a"}
+
+				Expect(update(project).Response.Allowed).To(BeTrue())
+			})
+
+			It("permits the update of a project being deleted", func() {
+				project := *oldProject.DeepCopy()
+				project.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+				project.Spec.Access = append(project.Spec.Access, user("carol"))
+
+				Expect(update(project).Response.Allowed).To(BeTrue())
+			})
+
+			It("permits spec changes that add no violation", func() {
+				project := *oldProject.DeepCopy()
+				project.Spec.Access[2] = user("carol")
+
+				Expect(update(project).Response.Allowed).To(BeTrue())
+			})
+
+			It("denies growing the members past the maximum", func() {
+				project := *oldProject.DeepCopy()
+				project.Spec.Access = append(project.Spec.Access, user("carol"))
+
+				admissionReview := update(project)
+				Expect(admissionReview.Response.Allowed).To(BeFalse())
+				Expect(admissionReview.Response.Result.Message).To(Equal(`Project.projects.vmware.com "kube-legacy" is invalid: spec.access: Too many: 5: must have at most 2 items`))
+			})
+
+			It("denies new duplicate and invalid subjects", func() {
+				project := *oldProject.DeepCopy()
+				project.Spec.Access = []projects.SubjectRef{user("alice"), user("alice"), user("alice"), {Kind: rbacv1.ServiceAccountKind, Name: "builder"}}
+
+				admissionReview := update(project)
+				Expect(admissionReview.Response.Allowed).To(BeFalse())
+
+				var fields []string
+				for _, cause := range admissionReview.Response.Result.Details.Causes {
+					fields = append(fields, cause.Field)
+				}
+				Expect(fields).To(Equal([]string{"spec.access[2]", "spec.access[3].namespace"}))
+			})
+		})
+
+		It("warns about projects without access", func() {
+			request := testhelpers.ValidRequestForProjectWebhookAPI(http.MethodPost, "/project", "my-project", false)
+			h.ServeHTTP(responseRecorder, testhelpers.WithOperation(request, admissionv1.Update))
+
+			Expect(review().Response.Warnings).To(ConsistOf("spec.access is empty, no subject is given access to the project"))
+		})
+	})
+
+	Describe("parent", func() {
+		var fakeProjectFetcher *webhookfakes.FakeProjectFetcher
+
+		BeforeEach(func() {
+			fakeProjectFetcher = new(webhookfakes.FakeProjectFetcher)
+			fakeProjectFetcher.GetProjectsReturns([]projects.Project{
+				{ObjectMeta: metav1.ObjectMeta{Name: "department"}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "team"}, Spec: projects.ProjectSpec{Parent: "department"}},
+			}, nil)
+
+			h = NewHandler(logr.Discard(), Config{}, fakeNamespaceFetcher, fakeProjectFetcher, nil, nil)
+		})
+
+		review := func() *admissionv1.AdmissionReview {
+			response, err := ioutil.ReadAll(responseRecorder.Result().Body)
+			Expect(err).NotTo(HaveOccurred())
+
+			var admissionReview *admissionv1.AdmissionReview
+			Expect(json.Unmarshal(response, &admissionReview)).To(Succeed())
+
+			return admissionReview
+		}
+
+		It("permits a parent in the hierarchy", func() {
+			h.ServeHTTP(responseRecorder, testhelpers.ValidRequestWithParentForProjectWebhookAPI(http.MethodPost, "/project", "my-project", "team"))
+
+			Expect(review().Response.Allowed).To(BeTrue())
+		})
+
+		It("denies a parent that would create a cycle", func() {
+			request := testhelpers.ValidRequestWithParentForProjectWebhookAPI(http.MethodPost, "/project", "department", "team")
+			h.ServeHTTP(responseRecorder, testhelpers.WithOperation(request, admissionv1.Update))
+
+			admissionReview := review()
+			Expect(admissionReview.Response.Allowed).To(BeFalse())
+			Expect(admissionReview.Response.Result.Message).To(Equal(`Project.projects.vmware.com "department" is invalid: spec.parent: Invalid value: "team": project hierarchy contains a cycle: department -> team -> department`))
+		})
+
+		It("denies a project that is its own parent", func() {
+			h.ServeHTTP(responseRecorder, testhelpers.ValidRequestWithParentForProjectWebhookAPI(http.MethodPost, "/project", "my-project", "my-project"))
+
+			Expect(review().Response.Allowed).To(BeFalse())
+		})
+
+		When("fetching the projects fails", func() {
+			It("denies the admission with an internal error", func() {
+				fakeProjectFetcher.GetProjectsReturns(nil, errors.New("boom"))
+				h.ServeHTTP(responseRecorder, testhelpers.ValidRequestWithParentForProjectWebhookAPI(http.MethodPost, "/project", "my-project", "team"))
+
+				admissionReview := review()
+				Expect(admissionReview.Response.Allowed).To(BeFalse())
+				Expect(admissionReview.Response.Result.Code).To(Equal(int32(http.StatusInternalServerError)))
+			})
+		})
+	})
 })
@@ -0,0 +1,170 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package webhook
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const serviceAccountUsernamePrefix = "system:serviceaccount:"
+
+// validateProject returns an error for each invalid field of the project and
+// a warning for each field that is legal but most likely a mistake. On
+// update the old project is given and only new violations are rejected, so
+// that projects created before a rule was added or a maximum lowered can
+// still be updated and deleted: projects being deleted or whose spec did not
+// change are not validated, the immutable name is not checked again, and
+// fields are only checked when they change.
+func (c Config) validateProject(project projects.Project, old *projects.Project, now time.Time) (field.ErrorList, []string) {
+	if !project.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+
+	var errs field.ErrorList
+	if old == nil {
+		errs = c.validateName(project.Name)
+	} else if equality.Semantic.DeepEqual(old.Spec, project.Spec) {
+		return nil, nil
+	}
+
+	var oldAccess []projects.SubjectRef
+	if old != nil {
+		oldAccess = old.Spec.Access
+	}
+	accessErrs, warnings := c.validateAccess(project.Spec.Access, oldAccess, now)
+	errs = append(errs, accessErrs...)
+
+	if old == nil || !equality.Semantic.DeepEqual(old.Spec.Quota, project.Spec.Quota) {
+		errs = append(errs, c.validateQuota(project)...)
+	}
+	if old == nil || !equality.Semantic.DeepEqual(old.Spec.Limits, project.Spec.Limits) {
+		errs = append(errs, c.validateLimits(project)...)
+	}
+	if old == nil || !equality.Semantic.DeepEqual(old.Spec.PodSecurity, project.Spec.PodSecurity) {
+		errs = append(errs, c.validatePodSecurity(project)...)
+	}
+
+	return errs, warnings
+}
+
+// validateName checks that the project name can be used as the name of its
+// namespace and is not reserved
+func (c Config) validateName(name string) field.ErrorList {
+	var errs field.ErrorList
+
+	path := field.NewPath("metadata", "name")
+	for _, message := range validation.IsDNS1123Label(name) {
+		errs = append(errs, field.Invalid(path, name, message))
+	}
+
+	if strings.HasPrefix(name, "kube-") || name == "default" || (c.OperatorNamespace != "" && name == c.OperatorNamespace) {
+		errs = append(errs, field.Forbidden(path, fmt.Sprintf("'%s' is a reserved namespace name", name)))
+	}
+
+	return errs
+}
+
+// validateAccess checks the subjects of the project and their number. The
+// subjects already in the old access are not checked again, and the number of
+// subjects may stay above the maximum as long as it does not grow.
+func (c Config) validateAccess(access, oldAccess []projects.SubjectRef, now time.Time) (field.ErrorList, []string) {
+	var (
+		errs     field.ErrorList
+		warnings []string
+	)
+
+	path := field.NewPath("spec", "access")
+	if len(access) == 0 {
+		warnings = append(warnings, "spec.access is empty, no subject is given access to the project")
+	}
+	if c.MaxMembers > 0 && len(access) > c.MaxMembers && len(access) > len(oldAccess) {
+		errs = append(errs, field.TooMany(path, len(access), c.MaxMembers))
+	}
+
+	oldSeen := map[projects.SubjectRef]int{}
+	for _, subject := range oldAccess {
+		oldSeen[subjectKey(subject)]++
+	}
+
+	seen := map[projects.SubjectRef]int{}
+	for i, subject := range access {
+		subjectPath := path.Index(i)
+		existing := containsSubject(oldAccess, subject)
+
+		if subject.Name == "" && !existing {
+			errs = append(errs, field.Required(subjectPath.Child("name"), ""))
+		}
+
+		switch {
+		case subject.Kind != rbacv1.ServiceAccountKind:
+			if subject.Namespace != "" {
+				warnings = append(warnings, fmt.Sprintf("%s: namespace is ignored for %s subjects", subjectPath.Child("namespace"), subject.Kind))
+			}
+		case existing:
+			// subjects already in the old access were checked when added
+		case subject.Namespace == "":
+			errs = append(errs, field.Required(subjectPath.Child("namespace"), "required for ServiceAccount subjects"))
+		default:
+			for _, message := range validation.IsDNS1123Label(subject.Namespace) {
+				errs = append(errs, field.Invalid(subjectPath.Child("namespace"), subject.Namespace, message))
+			}
+		}
+
+		// a subject may be listed once for each role, as each role is bound
+		// separately and the hierarchy grants every role of a subject.
+		// Duplicates the old access already had are not new violations.
+		key := subjectKey(subject)
+		seen[key]++
+		if seen[key] > 1 && seen[key] > oldSeen[key] {
+			value := describeSubject(subject)
+			if subject.Role != "" {
+				value = fmt.Sprintf("%s with role %s", value, subject.Role)
+			}
+			errs = append(errs, field.Duplicate(subjectPath, value))
+		}
+
+		if subject.Kind == rbacv1.UserKind && strings.HasPrefix(subject.Name, serviceAccountUsernamePrefix) {
+			warnings = append(warnings, fmt.Sprintf("%s: user '%s' is a service account, use kind ServiceAccount instead", subjectPath, subject.Name))
+		}
+
+		switch {
+		case subject.ExpiredAt(now):
+			warnings = append(warnings, fmt.Sprintf("%s: access of %s already expired at %s", subjectPath, describeSubject(subject), subject.ExpiresAt.UTC().Format(time.RFC3339)))
+		case subject.NotBefore != nil && subject.ExpiresAt != nil && !subject.NotBefore.Before(subject.ExpiresAt):
+			warnings = append(warnings, fmt.Sprintf("%s: notBefore is not before expiresAt, %s never has access", subjectPath, describeSubject(subject)))
+		}
+	}
+
+	return errs, warnings
+}
+
+// subjectKey identifies a subject and its role, leaving out when its access
+// starts and expires
+func subjectKey(subject projects.SubjectRef) projects.SubjectRef {
+	return projects.SubjectRef{Kind: subject.Kind, Namespace: subject.Namespace, Name: subject.Name, Role: subject.Role}
+}
+
+func containsSubject(access []projects.SubjectRef, subject projects.SubjectRef) bool {
+	for _, existing := range access {
+		if equality.Semantic.DeepEqual(existing, subject) {
+			return true
+		}
+	}
+	return false
+}
+
+func describeSubject(subject projects.SubjectRef) string {
+	if subject.Kind == rbacv1.ServiceAccountKind {
+		return fmt.Sprintf("%s %s/%s", subject.Kind, subject.Namespace, subject.Name)
+	}
+	return fmt.Sprintf("%s %s", subject.Kind, subject.Name)
+}
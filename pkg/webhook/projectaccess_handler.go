@@ -7,22 +7,29 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"reflect"
 
 	"github.com/go-logr/logr"
 	projects "github.com/pivotal/projects-operator/api/v1alpha1"
 	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type ProjectAccessHandler struct {
 	ProjectFetcher  ProjectFetcher
 	ProjectFilterer ProjectFilterer
+	AccessReviewer  AccessReviewer
+	config          Config
 	logger          logr.Logger
 }
 
-func NewProjectAccessHandler(logger logr.Logger, projectFetcher ProjectFetcher, projectFilterer ProjectFilterer) *ProjectAccessHandler {
+func NewProjectAccessHandler(logger logr.Logger, config Config, projectFetcher ProjectFetcher, projectFilterer ProjectFilterer, accessReviewer AccessReviewer) *ProjectAccessHandler {
 	return &ProjectAccessHandler{
 		ProjectFetcher:  projectFetcher,
 		ProjectFilterer: projectFilterer,
+		AccessReviewer:  accessReviewer,
+		config:          config,
 		logger:          logger,
 	}
 }
@@ -33,8 +40,7 @@ func (h *ProjectAccessHandler) HandleProjectAccess(w http.ResponseWriter, r *htt
 	// 1. Read the body
 	body, err := ensureBody(r.Body)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, `{"error": "%s"}`, err.Error())
+		sendError(w, nil, http.StatusBadRequest, fmt.Sprintf("error reading body: %s", err))
 
 		h.logger.Error(err, "error reading body")
 		return
@@ -43,8 +49,7 @@ func (h *ProjectAccessHandler) HandleProjectAccess(w http.ResponseWriter, r *htt
 	// 2. Unmarshal to AdmissionReview
 	arRequest, err := unmarshalToAdmissionReview(body)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, `{"error unmarshalling request body": "%s"}`, err)
+		sendError(w, nil, http.StatusBadRequest, fmt.Sprintf("error unmarshalling request body: %s", err))
 
 		h.logger.Error(err, "error unmarshaling AdmissionReview")
 		return
@@ -54,50 +59,74 @@ func (h *ProjectAccessHandler) HandleProjectAccess(w http.ResponseWriter, r *htt
 	raw := arRequest.Request.Object.Raw
 	projectAccess := projects.ProjectAccess{}
 	if err := json.Unmarshal(raw, &projectAccess); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, `{"error unmarshalling ProjectList": "%s"}`, err)
+		sendError(w, arRequest, http.StatusBadRequest, fmt.Sprintf("error unmarshalling ProjectAccess: %s", err))
 
 		h.logger.Error(err, "error unmarshaling ProjectAccess from AdmissionReview")
 		return
 	}
 
-	// 4. Grab the user and groups from the admissionreview.UserInfo
+	// 4. Grab the user and groups from the admissionreview.UserInfo, or from
+	// the spec when the requester may ask on behalf of another user. An update
+	// by the operator or the recorded user that keeps asking for the same user
+	// lists the projects of the user recorded when the ProjectAccess was
+	// created, anyone else goes through the impersonation check.
 	user := arRequest.Request.UserInfo
+	if recorded, ok := recordedUser(arRequest.Request, projectAccess); ok && (user.Username == recorded.Username || (h.config.OperatorUsername != "" && user.Username == h.config.OperatorUsername)) {
+		user = recorded
+	} else if projectAccess.Spec.User != "" || len(projectAccess.Spec.Groups) > 0 {
+		allowed, err := h.AccessReviewer.CanImpersonate(user)
+		if err != nil {
+			sendError(w, arRequest, http.StatusInternalServerError, fmt.Sprintf("error reviewing access: %s", err))
+
+			h.logger.Error(err, "error reviewing access", "user", user.Username)
+			return
+		}
+
+		if !allowed {
+			sendReview(w, arRequest, denied(http.StatusForbidden, metav1.StatusReasonForbidden,
+				fmt.Sprintf("user '%s' cannot list the projects of other users: %s on projectaccesses.%s is required",
+					user.Username, projects.ImpersonateVerb, projects.GroupVersion.Group)))
+			return
+		}
+
+		user = authenticationv1.UserInfo{
+			Username: projectAccess.Spec.User,
+			Groups:   projectAccess.Spec.Groups,
+		}
+	}
 
-	// 5. Grab a list of all projects
-	projects, err := h.ProjectFetcher.GetProjects()
+	// 5. Do some logic to determine list of projects for the user, and
+	// select the page asked for
+	filteredProjects, projectList, err := AccessibleProjects(h.config.ProjectAccessMode, h.ProjectFetcher, h.ProjectFilterer, user)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, `{"error fetching projects": "%s"}`, err.Error())
+		sendError(w, arRequest, http.StatusInternalServerError, fmt.Sprintf("error fetching projects: %s", err))
 
 		h.logger.Error(err, "error fetching Projects")
 		return
 	}
 
-	// 6. Do some logic to determine list of projects for the user
-	filteredProjects := h.ProjectFilterer.FilterProjects(projects, user)
+	page, continueToken, err := selectPage(filteredProjects, projectList, projectAccess.Spec)
+	if err != nil {
+		sendReview(w, arRequest, denied(http.StatusBadRequest, metav1.StatusReasonBadRequest, err.Error()))
+		return
+	}
 
-	// 7. Create a patch to update the status on the incoming ProjectAccess
-	patchBytes, err := createPatch(filteredProjects)
+	// 6. Create a patch to update the status on the incoming ProjectAccess
+	patchBytes, err := createPatch(page, continueToken, user)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, `{"error creating ProjectAccess patch": "%s"}`, err.Error())
+		sendError(w, arRequest, http.StatusInternalServerError, fmt.Sprintf("error creating ProjectAccess patch: %s", err))
 
 		h.logger.Error(err, "error creating ProjectAccess patch")
 		return
 	}
 
+	// 7. Send AdmissionReview
 	jsonPatchType := admissionv1.PatchTypeJSONPatch
-	arReview := &admissionv1.AdmissionReview{
-		Response: &admissionv1.AdmissionResponse{
-			Allowed:   true,
-			Patch:     patchBytes,
-			PatchType: &jsonPatchType,
-		},
-	}
-
-	// 8. Send AdmissionReview
-	sendReview(w, arReview)
+	sendReview(w, arRequest, &admissionv1.AdmissionResponse{
+		Allowed:   true,
+		Patch:     patchBytes,
+		PatchType: &jsonPatchType,
+	})
 }
 
 type PatchOperation struct {
@@ -106,16 +135,51 @@ type PatchOperation struct {
 	Value interface{} `json:"value,omitempty"`
 }
 
-func createPatch(projects []string) ([]byte, error) {
-	if projects == nil {
-		projects = []string{}
+func createPatch(accessibleProjects []projects.AccessibleProject, continueToken string, user authenticationv1.UserInfo) ([]byte, error) {
+	if accessibleProjects == nil {
+		accessibleProjects = []projects.AccessibleProject{}
+	}
+
+	status := map[string]interface{}{
+		"projects": accessibleProjects,
+	}
+	if continueToken != "" {
+		status["continue"] = continueToken
+	}
+	if user.Username != "" {
+		status["user"] = user.Username
+	}
+	if len(user.Groups) > 0 {
+		status["groups"] = user.Groups
 	}
 
 	return json.Marshal([]PatchOperation{{
-		Op:   "add",
-		Path: "/status",
-		Value: map[string]interface{}{
-			"projects": projects,
-		},
+		Op:    "add",
+		Path:  "/status",
+		Value: status,
 	}})
 }
+
+// recordedUser returns the user recorded in the status of the ProjectAccess
+// being updated, as long as the update asks for the same user
+func recordedUser(request *admissionv1.AdmissionRequest, projectAccess projects.ProjectAccess) (authenticationv1.UserInfo, bool) {
+	if request.Operation != admissionv1.Update || len(request.OldObject.Raw) == 0 {
+		return authenticationv1.UserInfo{}, false
+	}
+
+	oldProjectAccess := projects.ProjectAccess{}
+	if err := json.Unmarshal(request.OldObject.Raw, &oldProjectAccess); err != nil {
+		return authenticationv1.UserInfo{}, false
+	}
+
+	if oldProjectAccess.Status.User == "" ||
+		oldProjectAccess.Spec.User != projectAccess.Spec.User ||
+		!reflect.DeepEqual(oldProjectAccess.Spec.Groups, projectAccess.Spec.Groups) {
+		return authenticationv1.UserInfo{}, false
+	}
+
+	return authenticationv1.UserInfo{
+		Username: oldProjectAccess.Status.User,
+		Groups:   oldProjectAccess.Status.Groups,
+	}, true
+}
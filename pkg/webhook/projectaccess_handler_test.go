@@ -31,13 +31,14 @@ var _ = Describe("ProjectAccessHandler", func() {
 
 		fakeProjectFetcher  *webhookfakes.FakeProjectFetcher
 		fakeProjectFilterer *webhookfakes.FakeProjectFilterer
+		fakeAccessReviewer  *webhookfakes.FakeAccessReviewer
 	)
 
 	BeforeEach(func() {
 		responseRecorder = httptest.NewRecorder()
 
 		fakeProjectFetcher = new(webhookfakes.FakeProjectFetcher)
-		fakeProjectFetcher.GetProjectsReturns([]projects.Project{
+		fakeProjectFetcher.GetProjectsForUserReturns([]projects.Project{
 			{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "my-project-a",
@@ -80,10 +81,15 @@ var _ = Describe("ProjectAccessHandler", func() {
 		}, nil)
 
 		fakeProjectFilterer = new(webhookfakes.FakeProjectFilterer)
-		fakeProjectFilterer.FilterProjectsReturns([]string{"my-project-a", "my-project-c"})
+		fakeProjectFilterer.FilterProjectsReturns([]projects.AccessibleProject{
+			{Name: "my-project-a", Namespace: "my-project-a", Admin: true, Subjects: []projects.SubjectRef{{Kind: "Group", Name: "group-a"}}},
+			{Name: "my-project-c", Namespace: "my-project-c", Admin: true, Subjects: []projects.SubjectRef{{Kind: "User", Name: "developer"}}},
+		}, nil)
+
+		fakeAccessReviewer = new(webhookfakes.FakeAccessReviewer)
 
 		logger := logr.Discard()
-		h = NewHandler(logger, nil, fakeProjectFetcher, fakeProjectFilterer)
+		h = NewHandler(logger, Config{}, nil, fakeProjectFetcher, fakeProjectFilterer, fakeAccessReviewer)
 	})
 
 	It("handles POST /projectaccess", func() {
@@ -92,10 +98,24 @@ var _ = Describe("ProjectAccessHandler", func() {
 		Expect(responseRecorder.Result().StatusCode).To(Equal(http.StatusOK))
 	})
 
-	It("fetches all projects", func() {
+	It("fetches the projects of the user", func() {
 		h.ServeHTTP(responseRecorder, testhelpers.ValidRequestForProjectAccessWebhookAPI(http.MethodPost, "/projectaccess"))
 
-		Expect(fakeProjectFetcher.GetProjectsCallCount()).To(Equal(1))
+		Expect(fakeProjectFetcher.GetProjectsForUserCallCount()).To(Equal(1))
+		Expect(fakeProjectFetcher.GetProjectsForUserArgsForCall(0)).To(Equal(authenticationv1.UserInfo{Username: "developer", Groups: []string{"group-a"}}))
+	})
+
+	When("projects are found through RBAC", func() {
+		BeforeEach(func() {
+			h = NewHandler(logr.Discard(), Config{ProjectAccessMode: AccessModeRBAC}, nil, fakeProjectFetcher, fakeProjectFilterer, fakeAccessReviewer)
+		})
+
+		It("considers every project", func() {
+			h.ServeHTTP(responseRecorder, testhelpers.ValidRequestForProjectAccessWebhookAPI(http.MethodPost, "/projectaccess"))
+
+			Expect(fakeProjectFetcher.GetProjectsCallCount()).To(Equal(1))
+			Expect(fakeProjectFetcher.GetProjectsForUserCallCount()).To(Equal(0))
+		})
 	})
 
 	It("filters the projects for the user", func() {
@@ -124,22 +144,58 @@ var _ = Describe("ProjectAccessHandler", func() {
 		Expect(patch).To(HaveLen(1))
 		patchOperation := patch[0]
 		Expect(patchOperation.Path).To(Equal("/status"))
-		Expect(patchOperation.Value.(map[string]interface{})["projects"]).To(ConsistOf("my-project-a", "my-project-c"))
+		Expect(patchOperation.Value.(map[string]interface{})["projects"]).To(ConsistOf(
+			HaveKeyWithValue("name", "my-project-a"),
+			HaveKeyWithValue("name", "my-project-c"),
+		))
+
+		var status projects.ProjectAccessStatus
+		value, err := json.Marshal(patchOperation.Value)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(json.Unmarshal(value, &status)).To(Succeed())
+		Expect(status.Projects[0].Subjects).To(ConsistOf(projects.SubjectRef{Kind: "Group", Name: "group-a"}))
+		Expect(status.User).To(Equal("developer"))
+		Expect(status.Groups).To(Equal([]string{"group-a"}))
 	})
 
 	When("the ProjectFetcher returns an error", func() {
 		BeforeEach(func() {
-			fakeProjectFetcher.GetProjectsReturns([]projects.Project{}, errors.New("error-fetching-projects"))
+			fakeProjectFetcher.GetProjectsForUserReturns([]projects.Project{}, errors.New("error-fetching-projects"))
 		})
 
-		It("returns an internal server error", func() {
+		It("denies the admission with an internal error", func() {
 			h.ServeHTTP(responseRecorder, testhelpers.ValidRequestForProjectAccessWebhookAPI(http.MethodPost, "/projectaccess"))
 
-			body, err := ioutil.ReadAll(responseRecorder.Result().Body)
+			response, err := ioutil.ReadAll(responseRecorder.Result().Body)
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(string(body)).To(ContainSubstring("error fetching projects"))
-			Expect(responseRecorder.Result().StatusCode).To(Equal(http.StatusInternalServerError))
+			var admissionReview *admissionv1.AdmissionReview
+			Expect(json.Unmarshal(response, &admissionReview)).To(Succeed())
+
+			Expect(responseRecorder.Result().StatusCode).To(Equal(http.StatusOK))
+			Expect(admissionReview.Response.Allowed).To(BeFalse())
+			Expect(admissionReview.Response.Result.Code).To(Equal(int32(http.StatusInternalServerError)))
+			Expect(admissionReview.Response.Result.Message).To(Equal("error fetching projects: error-fetching-projects"))
+		})
+	})
+
+	When("the ProjectFilterer returns an error", func() {
+		BeforeEach(func() {
+			fakeProjectFilterer.FilterProjectsReturns(nil, errors.New("error-reviewing-projects"))
+		})
+
+		It("denies the admission with an internal error", func() {
+			h.ServeHTTP(responseRecorder, testhelpers.ValidRequestForProjectAccessWebhookAPI(http.MethodPost, "/projectaccess"))
+
+			response, err := ioutil.ReadAll(responseRecorder.Result().Body)
+			Expect(err).NotTo(HaveOccurred())
+
+			var admissionReview *admissionv1.AdmissionReview
+			Expect(json.Unmarshal(response, &admissionReview)).To(Succeed())
+
+			Expect(admissionReview.Response.Allowed).To(BeFalse())
+			Expect(admissionReview.Response.Result.Code).To(Equal(int32(http.StatusInternalServerError)))
+			Expect(admissionReview.Response.Result.Message).To(Equal("error fetching projects: error-reviewing-projects"))
 		})
 	})
 
@@ -157,4 +213,228 @@ var _ = Describe("ProjectAccessHandler", func() {
 			Expect(responseRecorder.Result().StatusCode).To(Equal(http.StatusBadRequest))
 		})
 	})
+
+	When("the request asks for the projects of another user", func() {
+		var request *http.Request
+
+		BeforeEach(func() {
+			request = testhelpers.ValidRequestForOtherUserProjectAccessWebhookAPI(http.MethodPost, "/projectaccess", "alice", []string{"group-b"})
+		})
+
+		It("reviews the access of the requester", func() {
+			h.ServeHTTP(responseRecorder, request)
+
+			Expect(fakeAccessReviewer.CanImpersonateCallCount()).To(Equal(1))
+			Expect(fakeAccessReviewer.CanImpersonateArgsForCall(0).Username).To(Equal("developer"))
+		})
+
+		When("the requester may impersonate", func() {
+			BeforeEach(func() {
+				fakeAccessReviewer.CanImpersonateReturns(true, nil)
+			})
+
+			It("filters the projects for the other user", func() {
+				h.ServeHTTP(responseRecorder, request)
+
+				Expect(fakeProjectFilterer.FilterProjectsCallCount()).To(Equal(1))
+				_, user := fakeProjectFilterer.FilterProjectsArgsForCall(0)
+				Expect(user).To(BeEquivalentTo(authenticationv1.UserInfo{Username: "alice", Groups: []string{"group-b"}}))
+			})
+		})
+
+		When("the requester may not impersonate", func() {
+			It("denies the admission", func() {
+				h.ServeHTTP(responseRecorder, request)
+
+				response, err := ioutil.ReadAll(responseRecorder.Result().Body)
+				Expect(err).NotTo(HaveOccurred())
+
+				var admissionReview *admissionv1.AdmissionReview
+				Expect(json.Unmarshal(response, &admissionReview)).To(Succeed())
+
+				Expect(admissionReview.Response.Allowed).To(BeFalse())
+				Expect(admissionReview.Response.Result.Message).To(Equal("user 'developer' cannot list the projects of other users: impersonate on projectaccesses.projects.vmware.com is required"))
+				Expect(fakeProjectFilterer.FilterProjectsCallCount()).To(Equal(0))
+			})
+		})
+
+		When("the access review fails", func() {
+			BeforeEach(func() {
+				fakeAccessReviewer.CanImpersonateReturns(false, errors.New("error-reviewing-access"))
+			})
+
+			It("denies the admission with an internal error", func() {
+				h.ServeHTTP(responseRecorder, request)
+
+				response, err := ioutil.ReadAll(responseRecorder.Result().Body)
+				Expect(err).NotTo(HaveOccurred())
+
+				var admissionReview *admissionv1.AdmissionReview
+				Expect(json.Unmarshal(response, &admissionReview)).To(Succeed())
+
+				Expect(admissionReview.Response.Allowed).To(BeFalse())
+				Expect(admissionReview.Response.Result.Code).To(Equal(int32(http.StatusInternalServerError)))
+			})
+		})
+	})
+
+	When("a ProjectAccess is updated", func() {
+		var oldProjectAccess projects.ProjectAccess
+
+		operator := authenticationv1.UserInfo{Username: "system:serviceaccount:projects-operator:default"}
+
+		BeforeEach(func() {
+			oldProjectAccess = projects.ProjectAccess{
+				Status: projects.ProjectAccessStatus{User: "alice", Groups: []string{"group-b"}},
+			}
+
+			h = NewHandler(logr.Discard(), Config{OperatorUsername: operator.Username}, nil, fakeProjectFetcher, fakeProjectFilterer, fakeAccessReviewer)
+		})
+
+		It("lists the projects of the recorded user for the operator", func() {
+			request := testhelpers.ValidUpdateRequestForProjectAccessWebhookAPI(http.MethodPost, "/projectaccess", oldProjectAccess, oldProjectAccess)
+			h.ServeHTTP(responseRecorder, testhelpers.WithUserInfo(request, operator))
+
+			_, user := fakeProjectFilterer.FilterProjectsArgsForCall(0)
+			Expect(user).To(BeEquivalentTo(authenticationv1.UserInfo{Username: "alice", Groups: []string{"group-b"}}))
+			Expect(fakeAccessReviewer.CanImpersonateCallCount()).To(Equal(0))
+		})
+
+		It("lists the projects of the recorded user for that user", func() {
+			request := testhelpers.ValidUpdateRequestForProjectAccessWebhookAPI(http.MethodPost, "/projectaccess", oldProjectAccess, oldProjectAccess)
+			h.ServeHTTP(responseRecorder, testhelpers.WithUserInfo(request, authenticationv1.UserInfo{Username: "alice"}))
+
+			_, user := fakeProjectFilterer.FilterProjectsArgsForCall(0)
+			Expect(user).To(BeEquivalentTo(authenticationv1.UserInfo{Username: "alice", Groups: []string{"group-b"}}))
+			Expect(fakeAccessReviewer.CanImpersonateCallCount()).To(Equal(0))
+		})
+
+		When("another user updates it", func() {
+			It("lists the projects of the requester rather than the recorded user", func() {
+				h.ServeHTTP(responseRecorder, testhelpers.ValidUpdateRequestForProjectAccessWebhookAPI(http.MethodPost, "/projectaccess", oldProjectAccess, oldProjectAccess))
+
+				_, user := fakeProjectFilterer.FilterProjectsArgsForCall(0)
+				Expect(user.Username).To(Equal("developer"))
+			})
+
+			It("denies asking for the recorded user without impersonation", func() {
+				oldProjectAccess.Spec.User = "alice"
+				fakeAccessReviewer.CanImpersonateReturns(false, nil)
+				h.ServeHTTP(responseRecorder, testhelpers.ValidUpdateRequestForProjectAccessWebhookAPI(http.MethodPost, "/projectaccess", oldProjectAccess, oldProjectAccess))
+
+				response, err := ioutil.ReadAll(responseRecorder.Result().Body)
+				Expect(err).NotTo(HaveOccurred())
+
+				var admissionReview *admissionv1.AdmissionReview
+				Expect(json.Unmarshal(response, &admissionReview)).To(Succeed())
+
+				Expect(admissionReview.Response.Allowed).To(BeFalse())
+				Expect(admissionReview.Response.Result.Code).To(Equal(int32(http.StatusForbidden)))
+				Expect(fakeAccessReviewer.CanImpersonateCallCount()).To(Equal(1))
+				Expect(fakeProjectFilterer.FilterProjectsCallCount()).To(Equal(0))
+			})
+		})
+
+		It("reviews the access of the requester when the update asks for another user", func() {
+			projectAccess := oldProjectAccess
+			projectAccess.Spec.User = "bob"
+			h.ServeHTTP(responseRecorder, testhelpers.ValidUpdateRequestForProjectAccessWebhookAPI(http.MethodPost, "/projectaccess", oldProjectAccess, projectAccess))
+
+			Expect(fakeAccessReviewer.CanImpersonateCallCount()).To(Equal(1))
+		})
+
+		It("lists the projects of the requester when no user was recorded", func() {
+			h.ServeHTTP(responseRecorder, testhelpers.ValidUpdateRequestForProjectAccessWebhookAPI(http.MethodPost, "/projectaccess", projects.ProjectAccess{}, projects.ProjectAccess{}))
+
+			_, user := fakeProjectFilterer.FilterProjectsArgsForCall(0)
+			Expect(user.Username).To(Equal("developer"))
+		})
+	})
+
+	Describe("selecting a page of projects", func() {
+		BeforeEach(func() {
+			projectList := []projects.Project{
+				{ObjectMeta: metav1.ObjectMeta{Name: "team-b", Labels: map[string]string{"tier": "web"}}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"tier": "web"}}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "other", Labels: map[string]string{"tier": "web"}}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "team-d", Labels: map[string]string{"tier": "web"}}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "team-c", Labels: map[string]string{"tier": "db"}}},
+			}
+			fakeProjectFetcher.GetProjectsForUserReturns(projectList, nil)
+
+			var accessibleProjects []projects.AccessibleProject
+			for _, project := range projectList {
+				accessibleProjects = append(accessibleProjects, projects.AccessibleProject{Name: project.Name})
+			}
+			fakeProjectFilterer.FilterProjectsReturns(accessibleProjects, nil)
+		})
+
+		status := func(spec projects.ProjectAccessSpec) projects.ProjectAccessStatus {
+			h.ServeHTTP(responseRecorder, testhelpers.ValidRequestWithSpecForProjectAccessWebhookAPI(http.MethodPost, "/projectaccess", spec))
+
+			response, err := ioutil.ReadAll(responseRecorder.Result().Body)
+			Expect(err).NotTo(HaveOccurred())
+			responseRecorder = httptest.NewRecorder()
+
+			var admissionReview *admissionv1.AdmissionReview
+			Expect(json.Unmarshal(response, &admissionReview)).To(Succeed())
+			Expect(admissionReview.Response.Allowed).To(BeTrue())
+
+			var patch []PatchOperation
+			Expect(json.Unmarshal(admissionReview.Response.Patch, &patch)).To(Succeed())
+			value, err := json.Marshal(patch[0].Value)
+			Expect(err).NotTo(HaveOccurred())
+
+			var status projects.ProjectAccessStatus
+			Expect(json.Unmarshal(value, &status)).To(Succeed())
+			return status
+		}
+
+		names := func(status projects.ProjectAccessStatus) []string {
+			var names []string
+			for _, accessible := range status.Projects {
+				names = append(names, accessible.Name)
+			}
+			return names
+		}
+
+		It("sorts the projects by name", func() {
+			Expect(names(status(projects.ProjectAccessSpec{}))).To(Equal([]string{"other", "team-a", "team-b", "team-c", "team-d"}))
+		})
+
+		It("filters the projects by label and name prefix", func() {
+			spec := projects.ProjectAccessSpec{
+				NamePrefix:    "team-",
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "web"}},
+			}
+			Expect(names(status(spec))).To(Equal([]string{"team-a", "team-b", "team-d"}))
+		})
+
+		It("pages through the projects", func() {
+			spec := projects.ProjectAccessSpec{NamePrefix: "team-", Limit: 2}
+
+			firstPage := status(spec)
+			Expect(names(firstPage)).To(Equal([]string{"team-a", "team-b"}))
+			Expect(firstPage.Continue).NotTo(BeEmpty())
+
+			spec.Continue = firstPage.Continue
+			secondPage := status(spec)
+			Expect(names(secondPage)).To(Equal([]string{"team-c", "team-d"}))
+			Expect(secondPage.Continue).To(BeEmpty())
+		})
+
+		When("the continue token is invalid", func() {
+			It("denies the admission", func() {
+				h.ServeHTTP(responseRecorder, testhelpers.ValidRequestWithSpecForProjectAccessWebhookAPI(http.MethodPost, "/projectaccess", projects.ProjectAccessSpec{Continue: "!"}))
+
+				response, err := ioutil.ReadAll(responseRecorder.Result().Body)
+				Expect(err).NotTo(HaveOccurred())
+
+				var admissionReview *admissionv1.AdmissionReview
+				Expect(json.Unmarshal(response, &admissionReview)).To(Succeed())
+				Expect(admissionReview.Response.Allowed).To(BeFalse())
+				Expect(admissionReview.Response.Result.Message).To(Equal("invalid continue token"))
+			})
+		})
+	})
 })
@@ -0,0 +1,105 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package webhook
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+var errInvalidContinueToken = errors.New("invalid continue token")
+
+// AccessibleProjects returns the projects the user has access to, sorted by
+// name, and the projects they were chosen from. Access given by RBAC cannot
+// be looked up from spec.access, so in the rbac and union modes every project
+// is considered.
+func AccessibleProjects(mode AccessMode, fetcher ProjectFetcher, filterer ProjectFilterer, user authenticationv1.UserInfo) ([]projects.AccessibleProject, []projects.Project, error) {
+	var projectList []projects.Project
+	var err error
+	if mode == AccessModeRBAC || mode == AccessModeUnion {
+		projectList, err = fetcher.GetProjects()
+	} else {
+		projectList, err = fetcher.GetProjectsForUser(user)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	accessibleProjects, err := filterer.FilterProjects(projectList, user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return accessibleProjects, projectList, nil
+}
+
+// selectPage returns the page of accessible projects asked for by the spec,
+// sorted by name, and the continue token for the next page. Projects are
+// matched against their labels in projectList.
+func selectPage(accessibleProjects []projects.AccessibleProject, projectList []projects.Project, spec projects.ProjectAccessSpec) ([]projects.AccessibleProject, string, error) {
+	selector := labels.Everything()
+	if spec.LabelSelector != nil {
+		var err error
+		if selector, err = metav1.LabelSelectorAsSelector(spec.LabelSelector); err != nil {
+			return nil, "", fmt.Errorf("invalid label selector: %w", err)
+		}
+	}
+
+	after, err := decodeContinueToken(spec.Continue)
+	if err != nil {
+		return nil, "", err
+	}
+
+	projectLabels := make(map[string]map[string]string, len(projectList))
+	for _, project := range projectList {
+		projectLabels[project.Name] = project.Labels
+	}
+
+	var selected []projects.AccessibleProject
+	for _, accessible := range accessibleProjects {
+		if !strings.HasPrefix(accessible.Name, spec.NamePrefix) || (after != "" && accessible.Name <= after) {
+			continue
+		}
+		if !selector.Matches(labels.Set(projectLabels[accessible.Name])) {
+			continue
+		}
+		selected = append(selected, accessible)
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].Name < selected[j].Name
+	})
+
+	if spec.Limit <= 0 || int64(len(selected)) <= spec.Limit {
+		return selected, "", nil
+	}
+
+	page := selected[:spec.Limit]
+	return page, encodeContinueToken(page[len(page)-1].Name), nil
+}
+
+// the continue token is the name of the last project on the page
+func encodeContinueToken(name string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(name))
+}
+
+func decodeContinueToken(token string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+
+	name, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(name) == 0 {
+		return "", errInvalidContinueToken
+	}
+
+	return string(name), nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package webhook
+
+import (
+	"fmt"
+	"sort"
+
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// defaultQuota returns the project quota with the configured defaults added
+// for the resources it does not set, or nil when nothing was added
+func (c Config) defaultQuota(project projects.Project) *corev1.ResourceQuotaSpec {
+	if len(c.DefaultQuota) == 0 {
+		return nil
+	}
+
+	quota := &corev1.ResourceQuotaSpec{}
+	if project.Spec.Quota != nil {
+		quota = project.Spec.Quota.DeepCopy()
+	}
+	if quota.Hard == nil {
+		quota.Hard = corev1.ResourceList{}
+	}
+
+	changed := false
+	for name, quantity := range c.DefaultQuota {
+		if _, ok := quota.Hard[name]; !ok {
+			quota.Hard[name] = quantity
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	return quota
+}
+
+// defaultLimits returns a container LimitRange item with the configured
+// defaults for projects that set no limits, or nil when there are none
+func (c Config) defaultLimits(project projects.Project) []corev1.LimitRangeItem {
+	if len(c.DefaultContainerLimits) == 0 || len(project.Spec.Limits) > 0 {
+		return nil
+	}
+
+	return []corev1.LimitRangeItem{{
+		Type:    corev1.LimitTypeContainer,
+		Default: c.DefaultContainerLimits.DeepCopy(),
+	}}
+}
+
+// validateQuota returns an error for each quota of the project that exceeds
+// the configured maximums, or that is missing
+func (c Config) validateQuota(project projects.Project) field.ErrorList {
+	var errs field.ErrorList
+
+	var hard corev1.ResourceList
+	if project.Spec.Quota != nil {
+		hard = project.Spec.Quota.Hard
+	}
+
+	hardPath := field.NewPath("spec", "quota", "hard")
+	for _, name := range sortedNames(c.MaxQuota) {
+		max := c.MaxQuota[name]
+		quantity, ok := hard[name]
+		if !ok {
+			errs = append(errs, field.Required(hardPath.Key(string(name)), fmt.Sprintf("must be set to at most %s", max.String())))
+			continue
+		}
+		if quantity.Cmp(max) > 0 {
+			errs = append(errs, field.Invalid(hardPath.Key(string(name)), quantity.String(), fmt.Sprintf("exceeds the maximum of %s", max.String())))
+		}
+	}
+
+	return errs
+}
+
+// validateLimits returns an error for each container limit of the project
+// that exceeds the configured maximums
+func (c Config) validateLimits(project projects.Project) field.ErrorList {
+	var errs field.ErrorList
+
+	for i, item := range project.Spec.Limits {
+		if item.Type != corev1.LimitTypeContainer {
+			continue
+		}
+
+		itemPath := field.NewPath("spec", "limits").Index(i)
+		for _, name := range sortedNames(c.MaxContainerLimits) {
+			max := c.MaxContainerLimits[name]
+			for _, limit := range []struct {
+				field  string
+				values corev1.ResourceList
+			}{{"max", item.Max}, {"default", item.Default}, {"defaultRequest", item.DefaultRequest}} {
+				if quantity, ok := limit.values[name]; ok && quantity.Cmp(max) > 0 {
+					errs = append(errs, field.Invalid(itemPath.Child(limit.field).Key(string(name)), quantity.String(), fmt.Sprintf("exceeds the maximum of %s", max.String())))
+				}
+			}
+		}
+	}
+
+	return errs
+}
+
+func sortedNames(resources corev1.ResourceList) []corev1.ResourceName {
+	names := make([]corev1.ResourceName, 0, len(resources))
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+
+	return names
+}
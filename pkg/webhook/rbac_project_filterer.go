@@ -0,0 +1,310 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// reviewBatchSize is the number of access reviews run at once
+	reviewBatchSize = 20
+	// maxCachedReviews bounds the number of cached review results
+	maxCachedReviews = 10000
+)
+
+type rbacProjectFilterer struct {
+	reader   client.Reader
+	reviewer AccessReviewer
+	logger   logr.Logger
+	ttl      time.Duration
+
+	mutex   sync.Mutex
+	reviews map[reviewKey]cachedReview
+}
+
+type reviewKey struct {
+	user    string
+	project string
+	verb    string
+}
+
+type cachedReview struct {
+	allowed bool
+	expires time.Time
+}
+
+// NewRBACProjectFilterer returns a filterer that asks the API server which
+// projects the user may get, and which they may update as an admin. Only the
+// projects that the ClusterRoles bound to the user grant access to are
+// reviewed, so the reader must have the index added by
+// IndexClusterRoleBindings. Review results are cached for ttl.
+func NewRBACProjectFilterer(logger logr.Logger, reader client.Reader, reviewer AccessReviewer, ttl time.Duration) *rbacProjectFilterer {
+	return &rbacProjectFilterer{
+		reader:   reader,
+		reviewer: reviewer,
+		logger:   logger,
+		ttl:      ttl,
+		reviews:  map[reviewKey]cachedReview{},
+	}
+}
+
+func (f *rbacProjectFilterer) FilterProjects(projectList []projects.Project, user authenticationv1.UserInfo) ([]projects.AccessibleProject, error) {
+	rules, err := f.rules(user)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching RBAC rules: %w", err)
+	}
+
+	var names []string
+	for _, project := range projectList {
+		names = append(names, project.Name)
+	}
+
+	visible, err := f.allowed(user, rules, names, "get")
+	if err != nil {
+		return nil, err
+	}
+
+	var visibleNames []string
+	for _, name := range names {
+		if visible[name] {
+			visibleNames = append(visibleNames, name)
+		}
+	}
+
+	admin, err := f.allowed(user, rules, visibleNames, "update")
+	if err != nil {
+		return nil, err
+	}
+
+	var accessibleProjects []projects.AccessibleProject
+	for _, project := range projectList {
+		if !visible[project.Name] {
+			continue
+		}
+
+		namespace := project.Status.Namespace
+		if namespace == "" {
+			namespace = project.Name
+		}
+		accessibleProjects = append(accessibleProjects, projects.AccessibleProject{
+			Name:      project.Name,
+			Namespace: namespace,
+			Admin:     admin[project.Name],
+		})
+	}
+	sort.Slice(accessibleProjects, func(i, j int) bool {
+		return accessibleProjects[i].Name < accessibleProjects[j].Name
+	})
+
+	return accessibleProjects, nil
+}
+
+// rules returns the rules of the ClusterRoles bound to the user. Projects are
+// cluster scoped, so RoleBindings never give access to them.
+func (f *rbacProjectFilterer) rules(user authenticationv1.UserInfo) ([]rbacv1.PolicyRule, error) {
+	clusterRoles := map[string]bool{}
+	for _, key := range userAccessKeys(user) {
+		bindings := &rbacv1.ClusterRoleBindingList{}
+		if err := f.reader.List(context.TODO(), bindings, client.MatchingFields{BindingSubjectField: key}); err != nil {
+			return nil, err
+		}
+
+		for _, binding := range bindings.Items {
+			if binding.RoleRef.Kind == "ClusterRole" {
+				clusterRoles[binding.RoleRef.Name] = true
+			}
+		}
+	}
+
+	var rules []rbacv1.PolicyRule
+	for name := range clusterRoles {
+		clusterRole := &rbacv1.ClusterRole{}
+		if err := f.reader.Get(context.TODO(), client.ObjectKey{Name: name}, clusterRole); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		rules = append(rules, clusterRole.Rules...)
+	}
+
+	return rules, nil
+}
+
+// allowed returns whether the user may perform verb on each of the named
+// projects. Only the projects the rules grant verb on are reviewed, and when
+// a rule grants it on every project a single review of all projects is tried
+// first, falling back to the named projects if that is denied.
+func (f *rbacProjectFilterer) allowed(user authenticationv1.UserInfo, rules []rbacv1.PolicyRule, names []string, verb string) (map[string]bool, error) {
+	all, granted := grantedProjects(rules, verb)
+
+	if all && len(names) > 0 {
+		allowed, err := f.review(user, []string{""}, verb)
+		if err != nil {
+			return nil, err
+		}
+
+		if allowed[""] {
+			allowed = make(map[string]bool, len(names))
+			for _, name := range names {
+				allowed[name] = true
+			}
+			return allowed, nil
+		}
+	}
+
+	var candidates []string
+	for _, name := range names {
+		if granted[name] {
+			candidates = append(candidates, name)
+		}
+	}
+
+	return f.review(user, candidates, verb)
+}
+
+// grantedProjects returns whether the rules grant verb on every project, and
+// the projects they grant it on by name
+func grantedProjects(rules []rbacv1.PolicyRule, verb string) (bool, map[string]bool) {
+	all := false
+	granted := map[string]bool{}
+	for _, rule := range rules {
+		if !matchesRule(rule.APIGroups, projects.GroupVersion.Group) || !matchesRule(rule.Resources, "projects") || !matchesRule(rule.Verbs, verb) {
+			continue
+		}
+
+		if len(rule.ResourceNames) == 0 {
+			all = true
+		}
+		for _, name := range rule.ResourceNames {
+			granted[name] = true
+		}
+	}
+
+	return all, granted
+}
+
+func matchesRule(values []string, value string) bool {
+	for _, v := range values {
+		if v == value || v == rbacv1.ResourceAll {
+			return true
+		}
+	}
+	return false
+}
+
+// review returns whether the user may perform verb on each of the named
+// projects, where "" stands for every project. Reviews that are not cached
+// are run in batches of reviewBatchSize. If any review fails an error is
+// returned rather than a partial result, and the failure is not cached.
+func (f *rbacProjectFilterer) review(user authenticationv1.UserInfo, names []string, verb string) (map[string]bool, error) {
+	userKey := reviewUserKey(user)
+	now := time.Now()
+
+	allowed := make(map[string]bool, len(names))
+	var pending []string
+	for _, name := range names {
+		if result, ok := f.cached(reviewKey{user: userKey, project: name, verb: verb}, now); ok {
+			allowed[name] = result
+		} else {
+			pending = append(pending, name)
+		}
+	}
+
+	var resultMutex sync.Mutex
+	var reviewErr error
+	for start := 0; start < len(pending) && reviewErr == nil; start += reviewBatchSize {
+		end := start + reviewBatchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+
+		var wg sync.WaitGroup
+		for _, name := range pending[start:end] {
+			wg.Add(1)
+			go func(name string) {
+				defer wg.Done()
+
+				result, err := f.reviewer.CanAccessProject(user, name, verb)
+
+				resultMutex.Lock()
+				defer resultMutex.Unlock()
+
+				if err != nil {
+					f.logger.Error(err, "error reviewing project access", "user", user.Username, "project", name, "verb", verb)
+					if reviewErr == nil {
+						reviewErr = fmt.Errorf("error reviewing %s access to project '%s': %w", verb, name, err)
+					}
+					return
+				}
+				f.cache(reviewKey{user: userKey, project: name, verb: verb}, result, now)
+				allowed[name] = result
+			}(name)
+		}
+		wg.Wait()
+	}
+
+	if reviewErr != nil {
+		return nil, reviewErr
+	}
+
+	return allowed, nil
+}
+
+func (f *rbacProjectFilterer) cached(key reviewKey, now time.Time) (bool, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	review, ok := f.reviews[key]
+	if !ok || now.After(review.expires) {
+		return false, false
+	}
+
+	return review.allowed, true
+}
+
+func (f *rbacProjectFilterer) cache(key reviewKey, allowed bool, now time.Time) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if len(f.reviews) >= maxCachedReviews {
+		for cachedKey, review := range f.reviews {
+			if now.After(review.expires) {
+				delete(f.reviews, cachedKey)
+			}
+		}
+	}
+	if len(f.reviews) >= maxCachedReviews {
+		f.reviews = map[reviewKey]cachedReview{}
+	}
+
+	f.reviews[key] = cachedReview{allowed: allowed, expires: now.Add(f.ttl)}
+}
+
+// reviewUserKey identifies everything about the user that RBAC may decide on
+func reviewUserKey(user authenticationv1.UserInfo) string {
+	groups := append([]string{}, user.Groups...)
+	sort.Strings(groups)
+
+	var extra []string
+	for key, values := range user.Extra {
+		extra = append(extra, key+"="+strings.Join(values, ","))
+	}
+	sort.Strings(extra)
+
+	return strings.Join([]string{user.Username, user.UID, strings.Join(groups, ","), strings.Join(extra, ";")}, "\x00")
+}
@@ -0,0 +1,205 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package webhook_test
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/pivotal/projects-operator/pkg/webhook"
+	"github.com/pivotal/projects-operator/pkg/webhook/webhookfakes"
+)
+
+var _ = Describe("RBACProjectFilterer", func() {
+	var (
+		filterer           ProjectFilterer
+		fakeAccessReviewer *webhookfakes.FakeAccessReviewer
+		rbacObjects        []client.Object
+		projectsToFilter   []projects.Project
+		user               authenticationv1.UserInfo
+	)
+
+	clusterRole := func(name string, rules ...rbacv1.PolicyRule) *rbacv1.ClusterRole {
+		return &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Rules:      rules,
+		}
+	}
+
+	projectRule := func(verbs []string, names ...string) rbacv1.PolicyRule {
+		return rbacv1.PolicyRule{
+			APIGroups:     []string{projects.GroupVersion.Group},
+			Resources:     []string{"projects"},
+			Verbs:         verbs,
+			ResourceNames: names,
+		}
+	}
+
+	clusterRoleBinding := func(name, clusterRole string, subject rbacv1.Subject) *rbacv1.ClusterRoleBinding {
+		return &rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: clusterRole},
+			Subjects:   []rbacv1.Subject{subject},
+		}
+	}
+
+	reviews := func() []string {
+		var result []string
+		for i := 0; i < fakeAccessReviewer.CanAccessProjectCallCount(); i++ {
+			_, name, verb := fakeAccessReviewer.CanAccessProjectArgsForCall(i)
+			result = append(result, verb+" "+name)
+		}
+		return result
+	}
+
+	BeforeEach(func() {
+		fakeAccessReviewer = new(webhookfakes.FakeAccessReviewer)
+		fakeAccessReviewer.CanAccessProjectStub = func(_ authenticationv1.UserInfo, name, verb string) (bool, error) {
+			switch name {
+			case "project-1":
+				return true, nil
+			case "project-2":
+				return verb == "get", nil
+			default:
+				return false, nil
+			}
+		}
+
+		rbacObjects = []client.Object{
+			clusterRole("project-viewer", projectRule([]string{"get"}, "project-1", "project-2")),
+			clusterRole("project-admin", projectRule([]string{"get", "update"}, "project-1")),
+			clusterRoleBinding("alice-viewer", "project-viewer", rbacv1.Subject{Kind: rbacv1.UserKind, Name: "alice"}),
+			clusterRoleBinding("admins", "project-admin", rbacv1.Subject{Kind: rbacv1.GroupKind, Name: "admins"}),
+		}
+
+		projectsToFilter = []projects.Project{
+			{ObjectMeta: metav1.ObjectMeta{Name: "project-3"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "project-2"}, Status: projects.ProjectStatus{Namespace: "namespace-2"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "project-1"}},
+		}
+		user = authenticationv1.UserInfo{Username: "alice", Groups: []string{"admins"}}
+	})
+
+	JustBeforeEach(func() {
+		fakeClient := fake.NewClientBuilder().
+			WithScheme(clientgoscheme.Scheme).
+			WithObjects(rbacObjects...).
+			WithIndex(&rbacv1.ClusterRoleBinding{}, BindingSubjectField, BindingSubjectKeys).
+			Build()
+
+		filterer = NewRBACProjectFilterer(logr.Discard(), fakeClient, fakeAccessReviewer, time.Minute)
+	})
+
+	It("returns the projects the user may get, sorted by name", func() {
+		Expect(filterer.FilterProjects(projectsToFilter, user)).To(Equal([]projects.AccessibleProject{
+			{Name: "project-1", Namespace: "project-1", Admin: true},
+			{Name: "project-2", Namespace: "namespace-2", Admin: false},
+		}))
+	})
+
+	It("reviews only the projects that the cluster roles bound to the user grant", func() {
+		_, err := filterer.FilterProjects(projectsToFilter, user)
+		Expect(err).NotTo(HaveOccurred())
+
+		for i := 0; i < fakeAccessReviewer.CanAccessProjectCallCount(); i++ {
+			reviewedUser, _, _ := fakeAccessReviewer.CanAccessProjectArgsForCall(i)
+			Expect(reviewedUser).To(Equal(user))
+		}
+		Expect(reviews()).To(ConsistOf("get project-1", "get project-2", "update project-1"))
+	})
+
+	When("a cluster role is bound to a service account", func() {
+		BeforeEach(func() {
+			rbacObjects = append(rbacObjects, clusterRoleBinding("robot-viewer", "project-viewer", rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "robot", Namespace: "some-namespace"}))
+			user = authenticationv1.UserInfo{Username: "system:serviceaccount:some-namespace:robot"}
+		})
+
+		It("reviews the projects it grants to the service account", func() {
+			Expect(filterer.FilterProjects(projectsToFilter, user)).To(HaveLen(2))
+			Expect(reviews()).To(ConsistOf("get project-1", "get project-2"))
+		})
+	})
+
+	It("caches the reviews of a user", func() {
+		filterer.FilterProjects(projectsToFilter, user)
+		filterer.FilterProjects(projectsToFilter, user)
+
+		Expect(fakeAccessReviewer.CanAccessProjectCallCount()).To(Equal(3))
+	})
+
+	It("does not share cached reviews between users", func() {
+		filterer.FilterProjects(projectsToFilter, user)
+		filterer.FilterProjects(projectsToFilter, authenticationv1.UserInfo{Username: "alice"})
+
+		Expect(fakeAccessReviewer.CanAccessProjectCallCount()).To(Equal(5))
+	})
+
+	When("no cluster role grants access to projects", func() {
+		It("reviews nothing and returns no projects", func() {
+			Expect(filterer.FilterProjects(projectsToFilter, authenticationv1.UserInfo{Username: "bob"})).To(BeEmpty())
+			Expect(fakeAccessReviewer.CanAccessProjectCallCount()).To(BeZero())
+		})
+	})
+
+	When("a cluster role grants access to every project", func() {
+		BeforeEach(func() {
+			rbacObjects = append(rbacObjects,
+				clusterRole("all-projects", projectRule([]string{"get", "update"})),
+				clusterRoleBinding("robot-all-projects", "all-projects", rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "robot", Namespace: "some-namespace"}),
+			)
+			user = authenticationv1.UserInfo{Username: "system:serviceaccount:some-namespace:robot"}
+		})
+
+		It("reviews all projects at once", func() {
+			fakeAccessReviewer.CanAccessProjectReturns(true, nil)
+
+			var manyProjects []projects.Project
+			for i := 0; i < 50; i++ {
+				manyProjects = append(manyProjects, projects.Project{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("project-%02d", i)}})
+			}
+
+			Expect(filterer.FilterProjects(manyProjects, user)).To(HaveLen(50))
+			Expect(reviews()).To(ConsistOf("get ", "update "))
+		})
+
+		When("the review of all projects is denied", func() {
+			BeforeEach(func() {
+				rbacObjects = append(rbacObjects, clusterRoleBinding("robot-viewer", "project-viewer", rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "robot", Namespace: "some-namespace"}))
+			})
+
+			It("reviews the projects granted by name", func() {
+				Expect(filterer.FilterProjects(projectsToFilter, user)).To(HaveLen(2))
+				Expect(reviews()).To(ConsistOf("get ", "get project-1", "get project-2", "update "))
+			})
+		})
+	})
+
+	When("a review fails", func() {
+		BeforeEach(func() {
+			fakeAccessReviewer.CanAccessProjectReturns(false, errors.New("boom"))
+		})
+
+		It("returns an error and does not cache the failure", func() {
+			_, err := filterer.FilterProjects(projectsToFilter, user)
+			Expect(err).To(MatchError(ContainSubstring("boom")))
+			Expect(err).To(MatchError(MatchRegexp("error reviewing get access to project 'project-[12]'")))
+
+			_, err = filterer.FilterProjects(projectsToFilter, user)
+			Expect(err).To(HaveOccurred())
+			Expect(fakeAccessReviewer.CanAccessProjectCallCount()).To(Equal(4))
+		})
+	})
+})
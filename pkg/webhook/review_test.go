@@ -0,0 +1,145 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package webhook_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	"github.com/pivotal/projects-operator/pkg/podsecurity"
+	"github.com/pivotal/projects-operator/testhelpers"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/pivotal/projects-operator/pkg/webhook"
+	"github.com/pivotal/projects-operator/pkg/webhook/webhookfakes"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden AdmissionReview responses in testdata")
+
+var _ = Describe("AdmissionReview responses", func() {
+	var h http.Handler
+
+	BeforeEach(func() {
+		fakeNamespaceFetcher := new(webhookfakes.FakeNamespaceFetcher)
+		fakeNamespaceFetcher.NamespaceExistsStub = func(name string) (bool, error) {
+			if name == "broken-namespace" {
+				return false, errors.New("error-fetching-namespaces")
+			}
+			return name == "my-namespace-a", nil
+		}
+
+		fakeProjectFetcher := new(webhookfakes.FakeProjectFetcher)
+		fakeProjectFetcher.GetProjectReturns(&projects.Project{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-project"},
+			Spec: projects.ProjectSpec{
+				PodSecurity: &projects.PodSecurity{Enforce: projects.PodSecurityBaseline},
+			},
+		}, nil)
+
+		fakeProjectFilterer := new(webhookfakes.FakeProjectFilterer)
+		fakeProjectFilterer.FilterProjectsReturns([]projects.AccessibleProject{{Name: "my-project", Namespace: "my-project", Admin: true}}, nil)
+
+		fakeAccessReviewer := new(webhookfakes.FakeAccessReviewer)
+		fakeAccessReviewer.CanImpersonateReturns(false, nil)
+
+		config := Config{PodSecurity: podsecurity.Config{Default: projects.PodSecurityRestricted}}
+		h = NewHandler(logr.Discard(), config, fakeNamespaceFetcher, fakeProjectFetcher, fakeProjectFilterer, fakeAccessReviewer)
+	})
+
+	nonJSON := func(request *http.Request) *http.Request {
+		request.Body = ioutil.NopCloser(bytes.NewBufferString("non-json-body"))
+		return request
+	}
+
+	namespaceLabels := func(enforce string) map[string]string {
+		return map[string]string{
+			projects.ProjectLabel:    "my-project",
+			podsecurity.EnforceLabel: enforce,
+			podsecurity.AuditLabel:   enforce,
+			podsecurity.WarnLabel:    enforce,
+		}
+	}
+
+	DescribeTable("each path of the handlers",
+		func(golden string, request func() *http.Request, statusCode int) {
+			responseRecorder := httptest.NewRecorder()
+			h.ServeHTTP(responseRecorder, request())
+
+			Expect(responseRecorder.Result().StatusCode).To(Equal(statusCode))
+			Expect(responseRecorder.Result().Header.Get("Content-Type")).To(Equal("application/json"))
+
+			body, err := ioutil.ReadAll(responseRecorder.Result().Body)
+			Expect(err).NotTo(HaveOccurred())
+
+			path := filepath.Join("testdata", golden+".json")
+			if *updateGolden {
+				var indented bytes.Buffer
+				Expect(json.Indent(&indented, body, "", "  ")).To(Succeed())
+				indented.WriteString("\n")
+				Expect(ioutil.WriteFile(path, indented.Bytes(), 0644)).To(Succeed())
+			}
+
+			expected, err := ioutil.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(body).To(MatchJSON(expected))
+
+			var admissionReview admissionv1.AdmissionReview
+			Expect(json.Unmarshal(body, &admissionReview)).To(Succeed())
+			Expect(admissionReview.APIVersion).To(Equal("admission.k8s.io/v1"))
+			Expect(admissionReview.Kind).To(Equal("AdmissionReview"))
+			if statusCode == http.StatusOK {
+				Expect(string(admissionReview.Response.UID)).To(Equal(testhelpers.AdmissionRequestUID))
+			}
+			if admissionReview.Response.Allowed {
+				Expect(admissionReview.Response.Result).To(BeNil())
+			} else {
+				Expect(admissionReview.Response.Result.Code).NotTo(BeZero())
+			}
+		},
+		Entry("a valid project", "project-allowed", func() *http.Request {
+			return testhelpers.ValidRequestWithUsersForProjectWebhookAPI(http.MethodPost, "/project", "my-project")
+		}, http.StatusOK),
+		Entry("an invalid project", "project-invalid", func() *http.Request {
+			return testhelpers.ValidRequestForProjectWebhookAPI(http.MethodPost, "/project", "my-namespace-a", false)
+		}, http.StatusOK),
+		Entry("a project that cannot be validated", "project-error", func() *http.Request {
+			return testhelpers.ValidRequestWithUsersForProjectWebhookAPI(http.MethodPost, "/project", "broken-namespace")
+		}, http.StatusOK),
+		Entry("a body that is not an AdmissionReview", "project-bad-request", func() *http.Request {
+			return nonJSON(testhelpers.ValidRequestForProjectWebhookAPI(http.MethodPost, "/project", "my-project", false))
+		}, http.StatusBadRequest),
+		Entry("a project that is defaulted", "project-defaulted", func() *http.Request {
+			return testhelpers.ValidRequestForProjectWebhookAPI(http.MethodPost, "/project-create", "my-project", false)
+		}, http.StatusOK),
+		Entry("a project that needs no defaults", "project-unchanged", func() *http.Request {
+			return testhelpers.ValidRequestWithUsersForProjectWebhookAPI(http.MethodPost, "/project-create", "my-project")
+		}, http.StatusOK),
+		Entry("a namespace update", "namespace-allowed", func() *http.Request {
+			return testhelpers.ValidUpdateRequestForNamespaceWebhookAPI(http.MethodPost, "/namespace", "my-project", namespaceLabels("baseline"), namespaceLabels("restricted"))
+		}, http.StatusOK),
+		Entry("a namespace update lowering pod security", "namespace-denied", func() *http.Request {
+			return testhelpers.ValidUpdateRequestForNamespaceWebhookAPI(http.MethodPost, "/namespace", "my-project", namespaceLabels("baseline"), namespaceLabels("privileged"))
+		}, http.StatusOK),
+		Entry("a ProjectAccess", "projectaccess-patched", func() *http.Request {
+			return testhelpers.ValidRequestForProjectAccessWebhookAPI(http.MethodPost, "/projectaccess")
+		}, http.StatusOK),
+		Entry("a ProjectAccess for another user", "projectaccess-forbidden", func() *http.Request {
+			return testhelpers.ValidRequestForOtherUserProjectAccessWebhookAPI(http.MethodPost, "/projectaccess", "alice", nil)
+		}, http.StatusOK),
+		Entry("a ProjectAccess with an invalid continue token", "projectaccess-bad-continue", func() *http.Request {
+			return testhelpers.ValidRequestWithSpecForProjectAccessWebhookAPI(http.MethodPost, "/projectaccess", projects.ProjectAccessSpec{Continue: "not a token!"})
+		}, http.StatusOK),
+	)
+})
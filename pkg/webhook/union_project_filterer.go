@@ -0,0 +1,53 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package webhook
+
+import (
+	"sort"
+
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+)
+
+type unionProjectFilterer struct {
+	filterers []ProjectFilterer
+}
+
+// NewUnionProjectFilterer returns a filterer that lists the projects found by
+// any of the given filterers. The first filterer to find a project gives its
+// role and subjects, and the user is an admin if any of them says so. If any
+// filterer fails the union fails, rather than listing only some projects.
+func NewUnionProjectFilterer(filterers ...ProjectFilterer) unionProjectFilterer {
+	return unionProjectFilterer{filterers: filterers}
+}
+
+func (f unionProjectFilterer) FilterProjects(projectList []projects.Project, user authenticationv1.UserInfo) ([]projects.AccessibleProject, error) {
+	byName := map[string]*projects.AccessibleProject{}
+	for _, filterer := range f.filterers {
+		filtered, err := filterer.FilterProjects(projectList, user)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, accessible := range filtered {
+			if existing, ok := byName[accessible.Name]; ok {
+				existing.Admin = existing.Admin || accessible.Admin
+				continue
+			}
+
+			accessible := accessible
+			byName[accessible.Name] = &accessible
+		}
+	}
+
+	var accessibleProjects []projects.AccessibleProject
+	for _, accessible := range byName {
+		accessibleProjects = append(accessibleProjects, *accessible)
+	}
+	sort.Slice(accessibleProjects, func(i, j int) bool {
+		return accessibleProjects[i].Name < accessibleProjects[j].Name
+	})
+
+	return accessibleProjects, nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2019-2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package webhook_test
+
+import (
+	"errors"
+
+	projects "github.com/pivotal/projects-operator/api/v1alpha1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/pivotal/projects-operator/pkg/webhook"
+	"github.com/pivotal/projects-operator/pkg/webhook/webhookfakes"
+)
+
+var _ = Describe("UnionProjectFilterer", func() {
+	It("merges the projects found by each filterer", func() {
+		literal := new(webhookfakes.FakeProjectFilterer)
+		literal.FilterProjectsReturns([]projects.AccessibleProject{
+			{Name: "project-b", Namespace: "project-b", Role: "view", Subjects: []projects.SubjectRef{{Kind: "User", Name: "alice", Role: "view"}}},
+		}, nil)
+
+		rbac := new(webhookfakes.FakeProjectFilterer)
+		rbac.FilterProjectsReturns([]projects.AccessibleProject{
+			{Name: "project-a", Namespace: "project-a"},
+			{Name: "project-b", Namespace: "project-b", Admin: true},
+		}, nil)
+
+		filterer := NewUnionProjectFilterer(literal, rbac)
+		Expect(filterer.FilterProjects(nil, authenticationv1.UserInfo{Username: "alice"})).To(Equal([]projects.AccessibleProject{
+			{Name: "project-a", Namespace: "project-a"},
+			{Name: "project-b", Namespace: "project-b", Role: "view", Admin: true, Subjects: []projects.SubjectRef{{Kind: "User", Name: "alice", Role: "view"}}},
+		}))
+	})
+
+	It("fails when any filterer fails", func() {
+		literal := new(webhookfakes.FakeProjectFilterer)
+		literal.FilterProjectsReturns([]projects.AccessibleProject{{Name: "project-a", Namespace: "project-a"}}, nil)
+
+		rbac := new(webhookfakes.FakeProjectFilterer)
+		rbac.FilterProjectsReturns(nil, errors.New("error-reviewing-projects"))
+
+		_, err := NewUnionProjectFilterer(literal, rbac).FilterProjects(nil, authenticationv1.UserInfo{Username: "alice"})
+		Expect(err).To(MatchError("error-reviewing-projects"))
+	})
+})
@@ -5,77 +5,85 @@ import (
 	"sync"
 
 	"github.com/pivotal/projects-operator/pkg/webhook"
-	v1 "k8s.io/api/core/v1"
 )
 
 type FakeNamespaceFetcher struct {
-	GetNamespacesStub        func() ([]v1.Namespace, error)
-	getNamespacesMutex       sync.RWMutex
-	getNamespacesArgsForCall []struct {
+	NamespaceExistsStub        func(string) (bool, error)
+	namespaceExistsMutex       sync.RWMutex
+	namespaceExistsArgsForCall []struct {
+		arg1 string
 	}
-	getNamespacesReturns struct {
-		result1 []v1.Namespace
+	namespaceExistsReturns struct {
+		result1 bool
 		result2 error
 	}
-	getNamespacesReturnsOnCall map[int]struct {
-		result1 []v1.Namespace
+	namespaceExistsReturnsOnCall map[int]struct {
+		result1 bool
 		result2 error
 	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeNamespaceFetcher) GetNamespaces() ([]v1.Namespace, error) {
-	fake.getNamespacesMutex.Lock()
-	ret, specificReturn := fake.getNamespacesReturnsOnCall[len(fake.getNamespacesArgsForCall)]
-	fake.getNamespacesArgsForCall = append(fake.getNamespacesArgsForCall, struct {
-	}{})
-	fake.recordInvocation("GetNamespaces", []interface{}{})
-	fake.getNamespacesMutex.Unlock()
-	if fake.GetNamespacesStub != nil {
-		return fake.GetNamespacesStub()
+func (fake *FakeNamespaceFetcher) NamespaceExists(arg1 string) (bool, error) {
+	fake.namespaceExistsMutex.Lock()
+	ret, specificReturn := fake.namespaceExistsReturnsOnCall[len(fake.namespaceExistsArgsForCall)]
+	fake.namespaceExistsArgsForCall = append(fake.namespaceExistsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("NamespaceExists", []interface{}{arg1})
+	fake.namespaceExistsMutex.Unlock()
+	if fake.NamespaceExistsStub != nil {
+		return fake.NamespaceExistsStub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.getNamespacesReturns
+	fakeReturns := fake.namespaceExistsReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *FakeNamespaceFetcher) GetNamespacesCallCount() int {
-	fake.getNamespacesMutex.RLock()
-	defer fake.getNamespacesMutex.RUnlock()
-	return len(fake.getNamespacesArgsForCall)
+func (fake *FakeNamespaceFetcher) NamespaceExistsCallCount() int {
+	fake.namespaceExistsMutex.RLock()
+	defer fake.namespaceExistsMutex.RUnlock()
+	return len(fake.namespaceExistsArgsForCall)
 }
 
-func (fake *FakeNamespaceFetcher) GetNamespacesCalls(stub func() ([]v1.Namespace, error)) {
-	fake.getNamespacesMutex.Lock()
-	defer fake.getNamespacesMutex.Unlock()
-	fake.GetNamespacesStub = stub
+func (fake *FakeNamespaceFetcher) NamespaceExistsCalls(stub func(string) (bool, error)) {
+	fake.namespaceExistsMutex.Lock()
+	defer fake.namespaceExistsMutex.Unlock()
+	fake.NamespaceExistsStub = stub
 }
 
-func (fake *FakeNamespaceFetcher) GetNamespacesReturns(result1 []v1.Namespace, result2 error) {
-	fake.getNamespacesMutex.Lock()
-	defer fake.getNamespacesMutex.Unlock()
-	fake.GetNamespacesStub = nil
-	fake.getNamespacesReturns = struct {
-		result1 []v1.Namespace
+func (fake *FakeNamespaceFetcher) NamespaceExistsArgsForCall(i int) string {
+	fake.namespaceExistsMutex.RLock()
+	defer fake.namespaceExistsMutex.RUnlock()
+	argsForCall := fake.namespaceExistsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeNamespaceFetcher) NamespaceExistsReturns(result1 bool, result2 error) {
+	fake.namespaceExistsMutex.Lock()
+	defer fake.namespaceExistsMutex.Unlock()
+	fake.NamespaceExistsStub = nil
+	fake.namespaceExistsReturns = struct {
+		result1 bool
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeNamespaceFetcher) GetNamespacesReturnsOnCall(i int, result1 []v1.Namespace, result2 error) {
-	fake.getNamespacesMutex.Lock()
-	defer fake.getNamespacesMutex.Unlock()
-	fake.GetNamespacesStub = nil
-	if fake.getNamespacesReturnsOnCall == nil {
-		fake.getNamespacesReturnsOnCall = make(map[int]struct {
-			result1 []v1.Namespace
+func (fake *FakeNamespaceFetcher) NamespaceExistsReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.namespaceExistsMutex.Lock()
+	defer fake.namespaceExistsMutex.Unlock()
+	fake.NamespaceExistsStub = nil
+	if fake.namespaceExistsReturnsOnCall == nil {
+		fake.namespaceExistsReturnsOnCall = make(map[int]struct {
+			result1 bool
 			result2 error
 		})
 	}
-	fake.getNamespacesReturnsOnCall[i] = struct {
-		result1 []v1.Namespace
+	fake.namespaceExistsReturnsOnCall[i] = struct {
+		result1 bool
 		result2 error
 	}{result1, result2}
 }
@@ -83,8 +91,8 @@ func (fake *FakeNamespaceFetcher) GetNamespacesReturnsOnCall(i int, result1 []v1
 func (fake *FakeNamespaceFetcher) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.getNamespacesMutex.RLock()
-	defer fake.getNamespacesMutex.RUnlock()
+	fake.namespaceExistsMutex.RLock()
+	defer fake.namespaceExistsMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -6,9 +6,23 @@ import (
 
 	"github.com/pivotal/projects-operator/api/v1alpha1"
 	"github.com/pivotal/projects-operator/pkg/webhook"
+	v1 "k8s.io/api/authentication/v1"
 )
 
 type FakeProjectFetcher struct {
+	GetProjectStub        func(string) (*v1alpha1.Project, error)
+	getProjectMutex       sync.RWMutex
+	getProjectArgsForCall []struct {
+		arg1 string
+	}
+	getProjectReturns struct {
+		result1 *v1alpha1.Project
+		result2 error
+	}
+	getProjectReturnsOnCall map[int]struct {
+		result1 *v1alpha1.Project
+		result2 error
+	}
 	GetProjectsStub        func() ([]v1alpha1.Project, error)
 	getProjectsMutex       sync.RWMutex
 	getProjectsArgsForCall []struct {
@@ -21,10 +35,86 @@ type FakeProjectFetcher struct {
 		result1 []v1alpha1.Project
 		result2 error
 	}
+	GetProjectsForUserStub        func(v1.UserInfo) ([]v1alpha1.Project, error)
+	getProjectsForUserMutex       sync.RWMutex
+	getProjectsForUserArgsForCall []struct {
+		arg1 v1.UserInfo
+	}
+	getProjectsForUserReturns struct {
+		result1 []v1alpha1.Project
+		result2 error
+	}
+	getProjectsForUserReturnsOnCall map[int]struct {
+		result1 []v1alpha1.Project
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeProjectFetcher) GetProject(arg1 string) (*v1alpha1.Project, error) {
+	fake.getProjectMutex.Lock()
+	ret, specificReturn := fake.getProjectReturnsOnCall[len(fake.getProjectArgsForCall)]
+	fake.getProjectArgsForCall = append(fake.getProjectArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("GetProject", []interface{}{arg1})
+	fake.getProjectMutex.Unlock()
+	if fake.GetProjectStub != nil {
+		return fake.GetProjectStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getProjectReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeProjectFetcher) GetProjectCallCount() int {
+	fake.getProjectMutex.RLock()
+	defer fake.getProjectMutex.RUnlock()
+	return len(fake.getProjectArgsForCall)
+}
+
+func (fake *FakeProjectFetcher) GetProjectCalls(stub func(string) (*v1alpha1.Project, error)) {
+	fake.getProjectMutex.Lock()
+	defer fake.getProjectMutex.Unlock()
+	fake.GetProjectStub = stub
+}
+
+func (fake *FakeProjectFetcher) GetProjectArgsForCall(i int) string {
+	fake.getProjectMutex.RLock()
+	defer fake.getProjectMutex.RUnlock()
+	argsForCall := fake.getProjectArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeProjectFetcher) GetProjectReturns(result1 *v1alpha1.Project, result2 error) {
+	fake.getProjectMutex.Lock()
+	defer fake.getProjectMutex.Unlock()
+	fake.GetProjectStub = nil
+	fake.getProjectReturns = struct {
+		result1 *v1alpha1.Project
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeProjectFetcher) GetProjectReturnsOnCall(i int, result1 *v1alpha1.Project, result2 error) {
+	fake.getProjectMutex.Lock()
+	defer fake.getProjectMutex.Unlock()
+	fake.GetProjectStub = nil
+	if fake.getProjectReturnsOnCall == nil {
+		fake.getProjectReturnsOnCall = make(map[int]struct {
+			result1 *v1alpha1.Project
+			result2 error
+		})
+	}
+	fake.getProjectReturnsOnCall[i] = struct {
+		result1 *v1alpha1.Project
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeProjectFetcher) GetProjects() ([]v1alpha1.Project, error) {
 	fake.getProjectsMutex.Lock()
 	ret, specificReturn := fake.getProjectsReturnsOnCall[len(fake.getProjectsArgsForCall)]
@@ -80,11 +170,78 @@ func (fake *FakeProjectFetcher) GetProjectsReturnsOnCall(i int, result1 []v1alph
 	}{result1, result2}
 }
 
+func (fake *FakeProjectFetcher) GetProjectsForUser(arg1 v1.UserInfo) ([]v1alpha1.Project, error) {
+	fake.getProjectsForUserMutex.Lock()
+	ret, specificReturn := fake.getProjectsForUserReturnsOnCall[len(fake.getProjectsForUserArgsForCall)]
+	fake.getProjectsForUserArgsForCall = append(fake.getProjectsForUserArgsForCall, struct {
+		arg1 v1.UserInfo
+	}{arg1})
+	fake.recordInvocation("GetProjectsForUser", []interface{}{arg1})
+	fake.getProjectsForUserMutex.Unlock()
+	if fake.GetProjectsForUserStub != nil {
+		return fake.GetProjectsForUserStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getProjectsForUserReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeProjectFetcher) GetProjectsForUserCallCount() int {
+	fake.getProjectsForUserMutex.RLock()
+	defer fake.getProjectsForUserMutex.RUnlock()
+	return len(fake.getProjectsForUserArgsForCall)
+}
+
+func (fake *FakeProjectFetcher) GetProjectsForUserCalls(stub func(v1.UserInfo) ([]v1alpha1.Project, error)) {
+	fake.getProjectsForUserMutex.Lock()
+	defer fake.getProjectsForUserMutex.Unlock()
+	fake.GetProjectsForUserStub = stub
+}
+
+func (fake *FakeProjectFetcher) GetProjectsForUserArgsForCall(i int) v1.UserInfo {
+	fake.getProjectsForUserMutex.RLock()
+	defer fake.getProjectsForUserMutex.RUnlock()
+	argsForCall := fake.getProjectsForUserArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeProjectFetcher) GetProjectsForUserReturns(result1 []v1alpha1.Project, result2 error) {
+	fake.getProjectsForUserMutex.Lock()
+	defer fake.getProjectsForUserMutex.Unlock()
+	fake.GetProjectsForUserStub = nil
+	fake.getProjectsForUserReturns = struct {
+		result1 []v1alpha1.Project
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeProjectFetcher) GetProjectsForUserReturnsOnCall(i int, result1 []v1alpha1.Project, result2 error) {
+	fake.getProjectsForUserMutex.Lock()
+	defer fake.getProjectsForUserMutex.Unlock()
+	fake.GetProjectsForUserStub = nil
+	if fake.getProjectsForUserReturnsOnCall == nil {
+		fake.getProjectsForUserReturnsOnCall = make(map[int]struct {
+			result1 []v1alpha1.Project
+			result2 error
+		})
+	}
+	fake.getProjectsForUserReturnsOnCall[i] = struct {
+		result1 []v1alpha1.Project
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeProjectFetcher) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.getProjectMutex.RLock()
+	defer fake.getProjectMutex.RUnlock()
 	fake.getProjectsMutex.RLock()
 	defer fake.getProjectsMutex.RUnlock()
+	fake.getProjectsForUserMutex.RLock()
+	defer fake.getProjectsForUserMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
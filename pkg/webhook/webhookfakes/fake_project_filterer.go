@@ -10,23 +10,25 @@ import (
 )
 
 type FakeProjectFilterer struct {
-	FilterProjectsStub        func([]v1alpha1.Project, v1.UserInfo) []string
+	FilterProjectsStub        func([]v1alpha1.Project, v1.UserInfo) ([]v1alpha1.AccessibleProject, error)
 	filterProjectsMutex       sync.RWMutex
 	filterProjectsArgsForCall []struct {
 		arg1 []v1alpha1.Project
 		arg2 v1.UserInfo
 	}
 	filterProjectsReturns struct {
-		result1 []string
+		result1 []v1alpha1.AccessibleProject
+		result2 error
 	}
 	filterProjectsReturnsOnCall map[int]struct {
-		result1 []string
+		result1 []v1alpha1.AccessibleProject
+		result2 error
 	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeProjectFilterer) FilterProjects(arg1 []v1alpha1.Project, arg2 v1.UserInfo) []string {
+func (fake *FakeProjectFilterer) FilterProjects(arg1 []v1alpha1.Project, arg2 v1.UserInfo) ([]v1alpha1.AccessibleProject, error) {
 	var arg1Copy []v1alpha1.Project
 	if arg1 != nil {
 		arg1Copy = make([]v1alpha1.Project, len(arg1))
@@ -44,10 +46,10 @@ func (fake *FakeProjectFilterer) FilterProjects(arg1 []v1alpha1.Project, arg2 v1
 		return fake.FilterProjectsStub(arg1, arg2)
 	}
 	if specificReturn {
-		return ret.result1
+		return ret.result1, ret.result2
 	}
 	fakeReturns := fake.filterProjectsReturns
-	return fakeReturns.result1
+	return fakeReturns.result1, fakeReturns.result2
 }
 
 func (fake *FakeProjectFilterer) FilterProjectsCallCount() int {
@@ -56,7 +58,7 @@ func (fake *FakeProjectFilterer) FilterProjectsCallCount() int {
 	return len(fake.filterProjectsArgsForCall)
 }
 
-func (fake *FakeProjectFilterer) FilterProjectsCalls(stub func([]v1alpha1.Project, v1.UserInfo) []string) {
+func (fake *FakeProjectFilterer) FilterProjectsCalls(stub func([]v1alpha1.Project, v1.UserInfo) ([]v1alpha1.AccessibleProject, error)) {
 	fake.filterProjectsMutex.Lock()
 	defer fake.filterProjectsMutex.Unlock()
 	fake.FilterProjectsStub = stub
@@ -69,27 +71,30 @@ func (fake *FakeProjectFilterer) FilterProjectsArgsForCall(i int) ([]v1alpha1.Pr
 	return argsForCall.arg1, argsForCall.arg2
 }
 
-func (fake *FakeProjectFilterer) FilterProjectsReturns(result1 []string) {
+func (fake *FakeProjectFilterer) FilterProjectsReturns(result1 []v1alpha1.AccessibleProject, result2 error) {
 	fake.filterProjectsMutex.Lock()
 	defer fake.filterProjectsMutex.Unlock()
 	fake.FilterProjectsStub = nil
 	fake.filterProjectsReturns = struct {
-		result1 []string
-	}{result1}
+		result1 []v1alpha1.AccessibleProject
+		result2 error
+	}{result1, result2}
 }
 
-func (fake *FakeProjectFilterer) FilterProjectsReturnsOnCall(i int, result1 []string) {
+func (fake *FakeProjectFilterer) FilterProjectsReturnsOnCall(i int, result1 []v1alpha1.AccessibleProject, result2 error) {
 	fake.filterProjectsMutex.Lock()
 	defer fake.filterProjectsMutex.Unlock()
 	fake.FilterProjectsStub = nil
 	if fake.filterProjectsReturnsOnCall == nil {
 		fake.filterProjectsReturnsOnCall = make(map[int]struct {
-			result1 []string
+			result1 []v1alpha1.AccessibleProject
+			result2 error
 		})
 	}
 	fake.filterProjectsReturnsOnCall[i] = struct {
-		result1 []string
-	}{result1}
+		result1 []v1alpha1.AccessibleProject
+		result2 error
+	}{result1, result2}
 }
 
 func (fake *FakeProjectFilterer) Invocations() map[string][][]interface{} {
@@ -23,6 +23,7 @@ import (
 	projects "github.com/pivotal/projects-operator/api/v1alpha1"
 	admissionv1 "k8s.io/api/admission/v1"
 	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
@@ -276,6 +277,140 @@ func ValidRequestForProjectWebhookAPI(method, path, projectName string, requestW
 	return requestForWebhookAPI(method, path, projectJson, requestWithServiceAccount)
 }
 
+func ValidRequestForAdoptingProjectWebhookAPI(method, path, projectName string) *http.Request {
+	project := projects.Project{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: projectName,
+		},
+		Spec: projects.ProjectSpec{
+			AdoptExistingNamespace: true,
+		},
+	}
+	projectJson, err := json.Marshal(project)
+	Expect(err).NotTo(HaveOccurred())
+
+	return requestForWebhookAPI(method, path, projectJson, false)
+}
+
+func ValidRequestWithQuotaForProjectWebhookAPI(method, path, projectName string, quota *corev1.ResourceQuotaSpec, limits []corev1.LimitRangeItem) *http.Request {
+	project := projects.Project{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: projectName,
+		},
+		Spec: projects.ProjectSpec{
+			Quota:  quota,
+			Limits: limits,
+		},
+	}
+	projectJson, err := json.Marshal(project)
+	Expect(err).NotTo(HaveOccurred())
+
+	return requestForWebhookAPI(method, path, projectJson, false)
+}
+
+func ValidRequestWithPodSecurityForProjectWebhookAPI(method, path, projectName string, podSecurity *projects.PodSecurity) *http.Request {
+	project := projects.Project{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: projectName,
+		},
+		Spec: projects.ProjectSpec{
+			PodSecurity: podSecurity,
+		},
+	}
+	projectJson, err := json.Marshal(project)
+	Expect(err).NotTo(HaveOccurred())
+
+	return requestForWebhookAPI(method, path, projectJson, false)
+}
+
+func ValidRequestWithParentForProjectWebhookAPI(method, path, projectName, parent string) *http.Request {
+	project := projects.Project{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: projectName,
+		},
+		Spec: projects.ProjectSpec{
+			Parent: parent,
+		},
+	}
+	projectJson, err := json.Marshal(project)
+	Expect(err).NotTo(HaveOccurred())
+
+	return requestForWebhookAPI(method, path, projectJson, false)
+}
+
+func ValidRequestWithSpecForProjectWebhookAPI(method, path, projectName string, spec projects.ProjectSpec) *http.Request {
+	project := projects.Project{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: projectName,
+		},
+		Spec: spec,
+	}
+	projectJson, err := json.Marshal(project)
+	Expect(err).NotTo(HaveOccurred())
+
+	return requestForWebhookAPI(method, path, projectJson, false)
+}
+
+func UpdateRequestForProjectWebhookAPI(method, path string, oldProject, project projects.Project) *http.Request {
+	oldProjectJson, err := json.Marshal(oldProject)
+	Expect(err).NotTo(HaveOccurred())
+
+	projectJson, err := json.Marshal(project)
+	Expect(err).NotTo(HaveOccurred())
+
+	request := WithOperation(requestForWebhookAPI(method, path, projectJson, false), admissionv1.Update)
+
+	body, err := ioutil.ReadAll(request.Body)
+	Expect(err).NotTo(HaveOccurred())
+
+	arRequest := admissionv1.AdmissionReview{}
+	Expect(json.Unmarshal(body, &arRequest)).To(Succeed())
+	arRequest.Request.OldObject = k8sruntime.RawExtension{Raw: oldProjectJson}
+
+	body, err = json.Marshal(arRequest)
+	Expect(err).NotTo(HaveOccurred())
+	request.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+
+	return request
+}
+
+func ValidUpdateRequestForNamespaceWebhookAPI(method, path, namespaceName string, oldLabels, labels map[string]string) *http.Request {
+	return UpdateRequestForNamespaceWebhookAPI(method, path,
+		corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespaceName, Labels: oldLabels}},
+		corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespaceName, Labels: labels}},
+	)
+}
+
+func UpdateRequestForNamespaceWebhookAPI(method, path string, oldNamespace, namespace corev1.Namespace) *http.Request {
+	oldNamespaceJson, err := json.Marshal(oldNamespace)
+	Expect(err).NotTo(HaveOccurred())
+
+	namespaceJson, err := json.Marshal(namespace)
+	Expect(err).NotTo(HaveOccurred())
+
+	request := WithOperation(requestForWebhookAPI(method, path, namespaceJson, false), admissionv1.Update)
+
+	body, err := ioutil.ReadAll(request.Body)
+	Expect(err).NotTo(HaveOccurred())
+
+	arRequest := admissionv1.AdmissionReview{}
+	Expect(json.Unmarshal(body, &arRequest)).To(Succeed())
+	arRequest.Request.OldObject = k8sruntime.RawExtension{Raw: oldNamespaceJson}
+
+	body, err = json.Marshal(arRequest)
+	Expect(err).NotTo(HaveOccurred())
+	request.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+
+	return request
+}
+
+func CreateRequestForNamespaceWebhookAPI(method, path string, namespace corev1.Namespace) *http.Request {
+	namespaceJson, err := json.Marshal(namespace)
+	Expect(err).NotTo(HaveOccurred())
+
+	return WithOperation(requestForWebhookAPI(method, path, namespaceJson, false), admissionv1.Create)
+}
+
 func ValidRequestWithUsersForProjectWebhookAPI(method, path, projectName string) *http.Request {
 	project := projects.Project{
 		ObjectMeta: metav1.ObjectMeta{
@@ -308,12 +443,60 @@ func ValidRequestForProjectAccessWebhookAPI(method, path string) *http.Request {
 	return requestForWebhookAPI(method, path, projectAccessJson, false)
 }
 
+func ValidRequestForOtherUserProjectAccessWebhookAPI(method, path, user string, groups []string) *http.Request {
+	return ValidRequestWithSpecForProjectAccessWebhookAPI(method, path, projects.ProjectAccessSpec{
+		User:   user,
+		Groups: groups,
+	})
+}
+
+func ValidRequestWithSpecForProjectAccessWebhookAPI(method, path string, spec projects.ProjectAccessSpec) *http.Request {
+	projectAccess := projects.ProjectAccess{
+		Spec: spec,
+	}
+	projectAccessJson, err := json.Marshal(projectAccess)
+	Expect(err).NotTo(HaveOccurred())
+
+	return requestForWebhookAPI(method, path, projectAccessJson, false)
+}
+
+func ValidUpdateRequestForProjectAccessWebhookAPI(method, path string, oldProjectAccess, projectAccess projects.ProjectAccess) *http.Request {
+	oldProjectAccessJson, err := json.Marshal(oldProjectAccess)
+	Expect(err).NotTo(HaveOccurred())
+
+	projectAccessJson, err := json.Marshal(projectAccess)
+	Expect(err).NotTo(HaveOccurred())
+
+	request := WithOperation(requestForWebhookAPI(method, path, projectAccessJson, false), admissionv1.Update)
+
+	body, err := ioutil.ReadAll(request.Body)
+	Expect(err).NotTo(HaveOccurred())
+
+	arRequest := admissionv1.AdmissionReview{}
+	Expect(json.Unmarshal(body, &arRequest)).To(Succeed())
+	arRequest.Request.OldObject = k8sruntime.RawExtension{Raw: oldProjectAccessJson}
+
+	body, err = json.Marshal(arRequest)
+	Expect(err).NotTo(HaveOccurred())
+	request.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+
+	return request
+}
+
+// AdmissionRequestUID is the UID of the requests made to the webhook API
+const AdmissionRequestUID = "705ab4f5-6393-11e8-b7cc-42010a800002"
+
 func requestForWebhookAPI(method, path string, raw []byte, requestWithServiceAccount bool) *http.Request {
 	u, err := url.Parse(path)
 	Expect(err).NotTo(HaveOccurred())
 
 	arRequest := admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "admission.k8s.io/v1",
+			Kind:       "AdmissionReview",
+		},
 		Request: &admissionv1.AdmissionRequest{
+			UID:      AdmissionRequestUID,
 			UserInfo: authenticationv1.UserInfo{},
 			Object: k8sruntime.RawExtension{
 				Raw: raw,
@@ -333,3 +516,33 @@ func requestForWebhookAPI(method, path string, raw []byte, requestWithServiceAcc
 
 	return &http.Request{Method: method, URL: u, Body: ioutil.NopCloser(bytes.NewBuffer(body))}
 }
+
+func WithOperation(request *http.Request, operation admissionv1.Operation) *http.Request {
+	body, err := ioutil.ReadAll(request.Body)
+	Expect(err).NotTo(HaveOccurred())
+
+	arRequest := admissionv1.AdmissionReview{}
+	Expect(json.Unmarshal(body, &arRequest)).To(Succeed())
+	arRequest.Request.Operation = operation
+
+	body, err = json.Marshal(arRequest)
+	Expect(err).NotTo(HaveOccurred())
+	request.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+
+	return request
+}
+
+func WithUserInfo(request *http.Request, userInfo authenticationv1.UserInfo) *http.Request {
+	body, err := ioutil.ReadAll(request.Body)
+	Expect(err).NotTo(HaveOccurred())
+
+	arRequest := admissionv1.AdmissionReview{}
+	Expect(json.Unmarshal(body, &arRequest)).To(Succeed())
+	arRequest.Request.UserInfo = userInfo
+
+	body, err = json.Marshal(arRequest)
+	Expect(err).NotTo(HaveOccurred())
+	request.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+
+	return request
+}